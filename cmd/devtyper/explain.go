@@ -0,0 +1,185 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/parth/DevTyper/config"
+	"github.com/parth/DevTyper/monitor"
+)
+
+// explanation is everything devtyper decides about a command before running it
+type explanation struct {
+	Argv            []string        `json:"argv"`
+	Wrappers        []string        `json:"wrappers"`
+	Env             []string        `json:"env"`
+	Effective       []string        `json:"effective"`
+	Project         string          `json:"project,omitempty"`
+	Practice        string          `json:"practice,omitempty"`
+	Rules           []explainedRule `json:"rules"`
+	Type            string          `json:"type"`
+	Description     string          `json:"description"`
+	Interactive     bool            `json:"interactive"`
+	Suggestion      string          `json:"suggestion,omitempty"`
+	Rewrite         []string        `json:"rewrite,omitempty"`
+	RewriteEnv      []string        `json:"rewrite_env,omitempty"`
+	ExpectedSeconds float64         `json:"expected_seconds,omitempty"`
+	HistoryRuns     int             `json:"history_runs"`
+}
+
+type explainedRule struct {
+	Pattern     string `json:"pattern"`
+	Match       string `json:"match"`
+	Words       int    `json:"words"`
+	Type        string `json:"type"`
+	Description string `json:"description"`
+	Interactive bool   `json:"interactive"`
+	Source      string `json:"source"`
+	Chosen      bool   `json:"chosen"`
+}
+
+// runExplain implements "devtyper explain" and returns the exit status
+func runExplain(args []string) int {
+	fs := flag.NewFlagSet("explain", flag.ExitOnError)
+	jsonOut := fs.Bool("json", false, "Print the explanation as JSON")
+	configPath := fs.String("config", config.Path(), "Path to the config file")
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		fmt.Println("Usage: devtyper explain [-json] [-config file] <command>")
+		return 1
+	}
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		fmt.Printf("Error loading config: %v\n", err)
+		return 1
+	}
+	registry, err := monitor.NewRegistry(cfg.Rules)
+	if err != nil {
+		fmt.Printf("Error in detection rules: %v\n", err)
+		return 1
+	}
+	var redactor *monitor.Redactor
+	if !cfg.Redact.Disable {
+		if redactor, err = monitor.NewRedactor(cfg.Redact.Patterns); err != nil {
+			fmt.Printf("Error in redact pattern: %v\n", err)
+			return 1
+		}
+	}
+
+	argv := commandArgs(fs.Args())
+	detection := registry.Detect(argv)
+	project := monitor.Project{}
+	if cwd, err := os.Getwd(); err == nil {
+		project = monitor.DetectProject(cwd)
+		detection = project.Refine(detection)
+	}
+
+	ex := explanation{
+		Argv:        argv,
+		Wrappers:    detection.Command.Wrappers,
+		Env:         detection.Command.Env,
+		Effective:   detection.Command.Effective,
+		Project:     project.Describe(),
+		Type:        detection.Type.String(),
+		Description: detection.Description,
+		Interactive: detection.Interactive,
+		Suggestion:  detection.Suggestion,
+	}
+	if lang, source := monitor.TaskLanguage(detection.Type, project); lang != "" {
+		ex.Practice = lang + " (from " + source + ")"
+	}
+	for _, m := range registry.Matches(detection.Command.Effective) {
+		ex.Rules = append(ex.Rules, explainedRule{
+			Pattern:     m.Rule.Pattern,
+			Match:       string(m.Rule.Match),
+			Words:       m.Words,
+			Type:        m.Rule.Type.String(),
+			Description: m.Rule.Description,
+			Interactive: m.Rule.Interactive,
+			Source:      m.Rule.Source,
+			Chosen:      m.Rule == detection.Rule,
+		})
+	}
+	if detection.Interactive && detection.Rule.Rewrite != nil {
+		ex.Rewrite, ex.RewriteEnv, _ = rewriteCommand(detection)
+	}
+	if history, err := loadDurationHistory(); err == nil {
+		expected, runs := history.Expected(redactor.Redact(monitor.HistoryKey(detection.Command)))
+		ex.ExpectedSeconds = expected.Seconds()
+		ex.HistoryRuns = runs
+	} else {
+		fmt.Fprintf(os.Stderr, "Warning: could not load durations: %v\n", err)
+	}
+
+	if *jsonOut {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(ex); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return 1
+		}
+		return 0
+	}
+	ex.print()
+	return 0
+}
+
+func (ex *explanation) print() {
+	fmt.Printf("Argv:         %q\n", ex.Argv)
+	if len(ex.Wrappers) > 0 {
+		fmt.Printf("Wrappers:     %s\n", strings.Join(ex.Wrappers, " → "))
+	}
+	if len(ex.Env) > 0 {
+		fmt.Printf("Environment:  %s\n", strings.Join(ex.Env, " "))
+	}
+	fmt.Printf("Effective:    %s\n", monitor.QuoteArgs(ex.Effective))
+	if ex.Project != "" {
+		fmt.Printf("Project:      %s\n", ex.Project)
+	}
+	if ex.Practice != "" {
+		fmt.Printf("Practice:     %s\n", ex.Practice)
+	}
+
+	fmt.Println("Rules:")
+	if len(ex.Rules) == 0 {
+		fmt.Println("  (none matched)")
+	}
+	for _, r := range ex.Rules {
+		marker := " "
+		if r.Chosen {
+			marker = "*"
+		}
+		fmt.Printf("  %s %-24s %-6s %d word(s)  %-10s %s [%s]\n",
+			marker, r.Pattern, r.Match, r.Words, r.Type, r.Description, r.Source)
+	}
+
+	fmt.Printf("Type:         %s\n", ex.Type)
+	fmt.Printf("Description:  %s\n", ex.Description)
+	if ex.Interactive {
+		fmt.Println("Interactive:  yes")
+	} else {
+		fmt.Println("Interactive:  no")
+	}
+	if ex.Suggestion != "" && ex.Interactive {
+		fmt.Printf("Suggestion:   %s\n", ex.Suggestion)
+	}
+	if ex.Rewrite != nil {
+		rewrite := monitor.QuoteArgs(ex.Rewrite)
+		if len(ex.RewriteEnv) > 0 {
+			rewrite = strings.Join(ex.RewriteEnv, " ") + " " + rewrite
+		}
+		fmt.Printf("Rewrite:      %s\n", rewrite)
+	}
+	if ex.HistoryRuns > 0 {
+		expected := time.Duration(ex.ExpectedSeconds * float64(time.Second)).Round(time.Second)
+		fmt.Printf("Expected:     %s (median of %d runs)\n", expected, ex.HistoryRuns)
+	} else {
+		fmt.Println("Expected:     unknown (no previous runs)")
+	}
+}
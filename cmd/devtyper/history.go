@@ -0,0 +1,55 @@
+package main
+
+import (
+	"path/filepath"
+
+	"github.com/parth/DevTyper/config"
+	"github.com/parth/DevTyper/game"
+	"github.com/parth/DevTyper/monitor"
+)
+
+func loadDurationHistory() (*monitor.DurationHistory, error) {
+	dir, err := config.DataDir()
+	if err != nil {
+		return nil, err
+	}
+	return monitor.LoadDurationHistory(filepath.Join(dir, "durations.json"))
+}
+
+// Remember how long a successful run took so explain can predict the next one
+func recordDuration(task *monitor.Task, key string) error {
+	if task.Status() != monitor.TaskCompleted {
+		return nil
+	}
+	history, err := loadDurationHistory()
+	if err != nil {
+		return err
+	}
+	history.Record(key, task.Duration())
+	return history.Save()
+}
+
+func loadQuoteHistory() (*game.QuoteHistory, error) {
+	dir, err := config.DataDir()
+	if err != nil {
+		return nil, err
+	}
+	return game.LoadQuoteHistory(filepath.Join(dir, "quotes.json"))
+}
+
+func loadKeyStats() (*game.KeyStats, error) {
+	dir, err := config.DataDir()
+	if err != nil {
+		return nil, err
+	}
+	return game.LoadKeyStats(filepath.Join(dir, "keys.json"))
+}
+
+// zenDir is where texts typed in zen mode are saved
+func zenDir() (string, error) {
+	dir, err := config.DataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "zen"), nil
+}
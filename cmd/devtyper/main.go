@@ -4,16 +4,26 @@ import (
 	"bufio"
 	"flag"
 	"fmt"
+	"io"
 	"os"
 	"os/signal"
 	"strings"
 	"sync"
 	"syscall"
+	"time"
 
+	"github.com/parth/DevTyper/config"
 	"github.com/parth/DevTyper/game"
 	"github.com/parth/DevTyper/monitor"
 )
 
+// How long to wait for completion hooks and notifiers before the report
+const handlerWait = 5 * time.Second
+
+// Where progress and status messages go. With -report json stdout carries
+// only the report, so they go to stderr instead.
+var console io.Writer = os.Stdout
+
 // Add TaskContext struct to hold shared channels
 type TaskContext struct {
 	doneChan   chan struct{}
@@ -25,43 +35,124 @@ type TaskContext struct {
 
 // Handle task completion based on user preference
 func handleTask(ctx *TaskContext, keepAlive bool) {
-	select {
-	case <-ctx.task.Done:
-		<-ctx.doneChan // Wait for output to finish
-		if ctx.task.HasError() {
-			fmt.Printf("\nTask failed: %s\n", ctx.task.GetError())
-		} else {
-			fmt.Println("\nTask completed successfully!")
-		}
-		if !keepAlive {
+	ticker := time.NewTicker(500 * time.Millisecond)
+	defer ticker.Stop()
+	waiting := false
+
+	for {
+		select {
+		case <-ticker.C:
+			// Tell the user once each time the task starts waiting on a prompt
+			if ctx.task.WaitingForInput() && !waiting {
+				fmt.Fprintf(console, "\n[devtyper] Task is waiting for input: %s\nType your answer and press Enter.\n", ctx.task.Prompt())
+			}
+			waiting = ctx.task.WaitingForInput()
+			continue
+		case <-ctx.task.Done:
+			<-ctx.doneChan // Wait for output to finish
+			if ctx.task.HasError() {
+				fmt.Fprintf(console, "\nTask failed: %s\n", ctx.task.GetError())
+			} else {
+				fmt.Fprintln(console, "\nTask completed successfully!")
+			}
+			if !keepAlive {
+				ctx.task.Stop()
+			}
+		case <-ctx.sigChan:
+			fmt.Fprintln(console, "\nStopping task...")
 			ctx.task.Stop()
 		}
-	case <-ctx.sigChan:
-		fmt.Println("\nStopping task...")
-		ctx.task.Stop()
+		return
 	}
 }
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "explain" {
+		os.Exit(runExplain(os.Args[2:]))
+	}
+
 	forceExit := flag.Bool("force-exit", false, "Exit game immediately when task completes")
 	keepAlive := flag.Bool("keep-alive", true, "Keep command running after exiting game")
+	configPath := flag.String("config", config.Path(), "Path to the config file")
+	onComplete := flag.String("on-complete", "", "Shell command to run when the task succeeds")
+	onFailure := flag.String("on-failure", "", "Shell command to run when the task fails")
+	notify := flag.String("notify", "", "Comma-separated notifiers: notify-send, osc9, osc777, tmux")
+	logPath := flag.String("log", "", "Write task output to this file")
+	reportFormat := flag.String("report", "text", "Summary printed on exit: text, json or none")
+	reportFile := flag.String("report-file", "", "Also write the summary as JSON to this file")
+	textPath := flag.String("text", "", "Practice on text from a file, a directory of .txt/.md files, or - for stdin")
 	flag.Parse()
 
 	args := flag.Args()
 	if len(args) == 0 {
-		fmt.Println("Usage: devtyper [-force-exit] [-keep-alive] <command>")
+		fmt.Fprintln(console, "Usage: devtyper [-force-exit] [-keep-alive] [-notify list] [-on-complete cmd] [-on-failure cmd] [-log file] [-report format] [-report-file file] [-text file|dir|-] <command>")
+		fmt.Fprintln(console, "       devtyper explain [-json] <command>")
+		os.Exit(1)
+	}
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		fmt.Fprintf(console, "Error loading config: %v\n", err)
+		os.Exit(1)
+	}
+
+	switch *reportFormat {
+	case "text", "json", "none":
+	default:
+		fmt.Fprintf(console, "Unknown report format %q (want text, json or none)\n", *reportFormat)
+		os.Exit(1)
+	}
+	if *reportFormat == "json" {
+		console = os.Stderr
+	}
+
+	registry, err := monitor.NewRegistry(cfg.Rules)
+	if err != nil {
+		fmt.Fprintf(console, "Error in detection rules: %v\n", err)
+		os.Exit(1)
+	}
+
+	input, err := promptInput(*textPath)
+	if err != nil {
+		fmt.Fprintf(console, "Error: %v\n", err)
 		os.Exit(1)
 	}
 
-	// Join all args to detect command type
-	cmdString := strings.Join(args, " ")
-	_, description, isInteractive, argExample := monitor.DetectCommand(cmdString)
+	var text *game.TextGenerator
+	if *textPath != "" {
+		if text, err = loadText(*textPath); err != nil {
+			fmt.Fprintf(console, "Error loading text: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	args = commandArgs(args)
+	detection := registry.Detect(args)
+	project := monitor.Project{}
+	if cwd, err := os.Getwd(); err == nil {
+		project = monitor.DetectProject(cwd)
+		detection = project.Refine(detection)
+	}
+	description := detection.Description
+
+	var redactor *monitor.Redactor
+	if !cfg.Redact.Disable {
+		redactor, err = monitor.NewRedactor(cfg.Redact.Patterns)
+		if err != nil {
+			fmt.Fprintf(console, "Error in redact pattern: %v\n", err)
+			os.Exit(1)
+		}
+	}
 
-	if isInteractive {
-		fmt.Println("\nThis command requires interactive input.")
-		fmt.Println("To skip interactive mode, try using arguments instead:")
-		fmt.Printf("\n  %s\n\n", argExample)
-		fmt.Println("Exiting. Please retry with arguments.")
+	reader := bufio.NewReader(input)
+	var env []string
+	if canRewrite(detection) {
+		args, env, _ = offerRewrite(reader, detection, args, redactor)
+	} else if detection.Interactive {
+		fmt.Fprintln(console, "\nThis command requires interactive input.")
+		fmt.Fprintln(console, "To skip interactive mode, try using arguments instead:")
+		fmt.Fprintf(console, "\n  %s\n\n", detection.Suggestion)
+		fmt.Fprintln(console, "Exiting. Please retry with arguments.")
 		os.Exit(0)
 	}
 
@@ -73,19 +164,44 @@ func main() {
 		gameActive: false,
 	}
 	signal.Notify(ctx.sigChan, syscall.SIGINT, syscall.SIGTERM)
+	if len(env) > 0 {
+		ctx.task.SetEnv(env)
+	}
+
+	completion, err := newCompletion(cfg, *onComplete, *onFailure, *notify)
+	if err != nil {
+		fmt.Fprintf(console, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	completion.Attach(ctx.task)
+
+	if redactor != nil {
+		ctx.task.SetRedactor(redactor)
+	}
+
+	// Show what the wrappers are running, e.g. "sudo → docker pull nginx"
+	if cl := monitor.ParseCommandLine(args); len(cl.Wrappers) > 0 {
+		description += " (" + redactor.Redact(cl.Display()) + ")"
+	}
+
+	if *logPath != "" {
+		if err := ctx.task.SetLogFile(*logPath); err != nil {
+			fmt.Fprintf(console, "Error opening log file: %v\n", err)
+			os.Exit(1)
+		}
+	}
 
 	// Handle signals for clean shutdown
 	go func() {
 		<-ctx.sigChan
-		fmt.Print("\n") // New line after ^C
+		fmt.Fprint(console, "\n") // New line after ^C
 		ctx.task.Stop()
-		fmt.Print("\033[?25h") // Show cursor
+		fmt.Fprint(console, "\033[?25h") // Show cursor
 		os.Exit(0)
 	}()
 
 	// Get user input before starting task
-	fmt.Println("Want to practice typing while waiting? [Y/n]")
-	reader := bufio.NewReader(os.Stdin)
+	fmt.Fprintln(console, "Want to practice typing while waiting? [Y/n]")
 	response, _ := reader.ReadString('\n')
 	response = strings.TrimSpace(response)
 
@@ -94,7 +210,7 @@ func main() {
 		for output := range ctx.task.GetOutputChannel() {
 			ctx.outputMu.Lock()
 			if !ctx.gameActive {
-				fmt.Print(output) // Only print output when game is not active
+				fmt.Fprint(console, output) // Only print output when game is not active
 			}
 			ctx.outputMu.Unlock()
 		}
@@ -103,17 +219,18 @@ func main() {
 
 	// Start task after user input
 	if err := ctx.task.Start(); err != nil {
-		fmt.Printf("\nError starting task: %v\n", err)
+		fmt.Fprintf(console, "\nError starting task: %v\n", err)
 		cleanup()
 		os.Exit(1)
 	}
 
-	fmt.Printf("\nStarting task: %s\n", description)
+	fmt.Fprintf(console, "\nStarting task: %s\n", description)
 
+	var typing *game.Results
 	if strings.ToLower(response) != "n" {
-		g, err := game.New(ctx.task.Done, description, ctx.task)
+		g, err := game.New(ctx.task.Done, description, ctx.task, detection.Type, project)
 		if err != nil {
-			fmt.Printf("\nError starting game: %v\n", err)
+			fmt.Fprintf(console, "\nError starting game: %v\n", err)
 			ctx.task.Stop()
 			cleanup()
 			os.Exit(1)
@@ -126,6 +243,35 @@ func main() {
 
 		// Run game
 		g.ForceExit = *forceExit
+		g.Terminal = console
+		if text != nil {
+			g.SetText(text)
+		}
+		if home, err := os.UserHomeDir(); err == nil {
+			if shell, err := game.LoadShellHistory(home, redactor); err == nil {
+				g.SetShellHistory(shell)
+			}
+		}
+		// Load failures are reported once the game has left the screen
+		quoteHistory, quoteErr := loadQuoteHistory()
+		if quoteErr == nil {
+			g.QuoteHistory = quoteHistory
+		}
+		if dir, err := zenDir(); err == nil {
+			g.ZenDir = dir
+		}
+		keyStats, keyErr := loadKeyStats()
+		if keyErr == nil {
+			g.KeyStats = keyStats
+		}
+		g.Indent = game.IndentOptions{
+			Typed:   cfg.Typing.Indent.Typed,
+			UseTabs: cfg.Typing.Indent.Tabs,
+			Width:   cfg.Typing.Indent.Width,
+		}
+		if g.Indent.Width <= 0 {
+			g.Indent.Width = game.DefaultIndentOptions().Width
+		}
 		g.Run()
 
 		// Reset game active state to allow console output again
@@ -133,23 +279,118 @@ func main() {
 		ctx.gameActive = false
 		ctx.outputMu.Unlock()
 
+		results := g.Results()
+		typing = &results
+		if quoteErr != nil {
+			fmt.Fprintf(console, "Warning: could not load quote history: %v\n", quoteErr)
+		}
+		if keyErr != nil {
+			fmt.Fprintf(console, "Warning: could not load key statistics: %v\n", keyErr)
+		}
+		if text != nil {
+			if err := saveTextPosition(text); err != nil {
+				fmt.Fprintf(console, "Warning: could not save text position: %v\n", err)
+			}
+		}
+		if quoteHistory != nil && !quoteHistory.Empty() {
+			if err := quoteHistory.Save(); err != nil {
+				fmt.Fprintf(console, "Warning: could not save quote history: %v\n", err)
+			}
+		}
+		if keyStats != nil && len(keyStats.Keys) > 0 {
+			if err := keyStats.Save(); err != nil {
+				fmt.Fprintf(console, "Warning: could not save key statistics: %v\n", err)
+			}
+		}
+
 		// Show status after game exits
 		if ctx.task.IsComplete() {
-			fmt.Println("\nTask completed while playing!")
 			<-ctx.doneChan // Wait for output to finish
 			cleanup()
-			os.Exit(0)
+			fmt.Fprintln(console, "Task completed while playing!")
+		} else {
+			// Wait for task if it's still running
+			fmt.Fprintln(console, "\nTask is still running. Showing live output:")
+			go io.Copy(ctx.task, reader) // Let the user answer prompts
+			handleTask(ctx, *keepAlive)
+			cleanup()
 		}
-
-		// Wait for task if it's still running
-		fmt.Println("\nTask is still running. Showing live output:")
+	} else {
+		go io.Copy(ctx.task, reader)
 		handleTask(ctx, *keepAlive)
+		cleanup()
+	}
+
+	if !ctx.task.WaitHandlers(handlerWait) {
+		fmt.Fprintln(console, "Warning: completion hooks are still running")
+	}
+	printCompletionErrors(completion)
+	historyKey := redactor.Redact(monitor.HistoryKey(monitor.ParseCommandLine(args)))
+	if err := recordDuration(ctx.task, historyKey); err != nil {
+		fmt.Fprintf(console, "Warning: could not save task duration: %v\n", err)
+	}
+	report := newReport(ctx.task, description, typing)
+	if err := printReport(report, *reportFormat, *reportFile); err != nil {
+		fmt.Fprintf(console, "Error writing report: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// Turn the command-line arguments into the argv to run
+func commandArgs(args []string) []string {
+	// A quoted command line such as "kubectl apply -f x" arrives as one word,
+	// unless it names a program like "/opt/my tools/build.sh"
+	if len(args) == 1 && strings.ContainsAny(args[0], " \t") && !exists(args[0]) {
+		if words, err := monitor.SplitCommandLine(args[0]); err == nil && len(words) > 0 {
+			args = words
+		}
+	}
+	// Leading assignments like FOO=1 are not executable, run them through env
+	if monitor.IsAssignment(args[0]) {
+		args = append([]string{"env"}, args...)
 	}
+	return args
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
 
-	cleanup()
+// Flags override the hooks and notifiers from the config file
+func newCompletion(cfg *config.Config, onComplete, onFailure, notify string) (*monitor.Completion, error) {
+	completion := &monitor.Completion{
+		OnComplete: cfg.Hooks.OnComplete,
+		OnFailure:  cfg.Hooks.OnFailure,
+	}
+	if onComplete != "" {
+		completion.OnComplete = onComplete
+	}
+	if onFailure != "" {
+		completion.OnFailure = onFailure
+	}
+
+	names := cfg.Notify
+	if notify != "" {
+		names = strings.Split(notify, ",")
+	}
+	for _, name := range names {
+		n, err := monitor.NewNotifier(strings.TrimSpace(name), console)
+		if err != nil {
+			return nil, err
+		}
+		completion.Notifiers = append(completion.Notifiers, n)
+	}
+	return completion, nil
+}
+
+func printCompletionErrors(completion *monitor.Completion) {
+	for _, err := range completion.Errors() {
+		fmt.Fprintf(console, "Warning: %v\n", err)
+	}
 }
 
 func cleanup() {
-	fmt.Print("\033[?25h") // Show cursor
-	fmt.Print("\033[2J\033[H") // Clear screen
+	fmt.Fprint(console, "\033[?25h")     // Show cursor
+	fmt.Fprint(console, "\033[2J\033[H") // Clear screen
 }
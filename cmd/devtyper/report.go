@@ -0,0 +1,120 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/parth/DevTyper/game"
+	"github.com/parth/DevTyper/monitor"
+)
+
+// Report summarises a devtyper run: the task and the typing session
+type Report struct {
+	Command     string        `json:"command"`
+	Description string        `json:"description"`
+	Status      string        `json:"status"`
+	ExitCode    int           `json:"exit_code"`
+	Duration    float64       `json:"duration_seconds"`
+	OutputLines int           `json:"output_lines"`
+	Warnings    int           `json:"warnings"`
+	Error       string        `json:"error,omitempty"`
+	Typing      *game.Results `json:"typing,omitempty"`
+}
+
+func newReport(task *monitor.Task, description string, typing *game.Results) *Report {
+	report := &Report{
+		Command:     task.DisplayCommand(),
+		Description: description,
+		Status:      "running",
+		ExitCode:    task.ExitCode(),
+		Duration:    task.Duration().Seconds(),
+		OutputLines: task.LineCount(),
+		Warnings:    task.WarningCount(),
+		Error:       task.GetError(),
+		Typing:      typing,
+	}
+	switch task.Status() {
+	case monitor.TaskCompleted:
+		report.Status = "completed"
+	case monitor.TaskFailed:
+		report.Status = "failed"
+	case monitor.TaskWaitingInput:
+		report.Status = "waiting for input"
+	}
+	return report
+}
+
+func (r *Report) writeJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+func (r *Report) writeText(w io.Writer) {
+	border := strings.Repeat("=", 50)
+	fmt.Fprintln(w, border)
+	fmt.Fprintln(w, "DevTyper Summary")
+	fmt.Fprintln(w, border)
+	fmt.Fprintf(w, "Command: %s\n", r.Command)
+	fmt.Fprintf(w, "Status: %s", r.Status)
+	if r.ExitCode >= 0 {
+		fmt.Fprintf(w, " (exit code %d)", r.ExitCode)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "Duration: %.1f seconds\n", r.Duration)
+	fmt.Fprintf(w, "Output lines: %d\n", r.OutputLines)
+	fmt.Fprintf(w, "Warnings: %d\n", r.Warnings)
+	if r.Error != "" {
+		fmt.Fprintf(w, "Error: %s\n", r.Error)
+	}
+	if r.Typing != nil {
+		fmt.Fprintln(w, border)
+		fmt.Fprintf(w, "Typing time: %d seconds\n", r.Typing.Duration)
+		fmt.Fprintf(w, "Words per minute: %.1f\n", r.Typing.WPM)
+		fmt.Fprintf(w, "Accuracy: %.1f%%\n", r.Typing.Accuracy)
+		fmt.Fprintf(w, "Letter accuracy: %.1f%%\n", r.Typing.LetterAccuracy)
+		fmt.Fprintf(w, "Symbol accuracy: %.1f%%\n", r.Typing.SymbolAccuracy)
+		fmt.Fprintf(w, "Words typed: %d\n", r.Typing.WordsTyped)
+		if r.Typing.Rules != "" && r.Typing.Rules != "normal" {
+			fmt.Fprintf(w, "Rules: %s\n", r.Typing.Rules)
+		}
+		if len(r.Typing.Modifiers) > 0 {
+			fmt.Fprintf(w, "Modifiers: %s\n", strings.Join(r.Typing.Modifiers, ", "))
+		}
+		if r.Typing.RhythmMs > 0 {
+			fmt.Fprintf(w, "Rhythm: %.0f ms between keys (%.0f%% variation)\n", r.Typing.RhythmMs, r.Typing.RhythmVariation)
+		}
+		if len(r.Typing.OutputLines) > 0 {
+			fmt.Fprintf(w, "Output lines typed: %d\n", len(r.Typing.OutputLines))
+		}
+	}
+	fmt.Fprintln(w, border)
+}
+
+// Print the report in the requested format and optionally save it as JSON
+func printReport(r *Report, format, file string) error {
+	switch format {
+	case "text":
+		r.writeText(os.Stdout)
+	case "json":
+		if err := r.writeJSON(os.Stdout); err != nil {
+			return err
+		}
+	case "none":
+	default:
+		return fmt.Errorf("unknown report format %q", format)
+	}
+
+	if file == "" {
+		return nil
+	}
+	f, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return r.writeJSON(f)
+}
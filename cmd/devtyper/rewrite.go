@@ -0,0 +1,122 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/parth/DevTyper/config"
+	"github.com/parth/DevTyper/monitor"
+	"github.com/parth/DevTyper/store"
+)
+
+// Remembered answers to the rewrite prompt, keyed by the original command line
+type rewriteChoices map[string]string
+
+const (
+	choiceAlways = "always"
+	choiceNever  = "never"
+)
+
+func rewriteChoicesPath() (string, error) {
+	dir, err := config.DataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "rewrites.json"), nil
+}
+
+func loadRewriteChoices() (rewriteChoices, error) {
+	choices := rewriteChoices{}
+	path, err := rewriteChoicesPath()
+	if err != nil {
+		return choices, err
+	}
+	if err := store.Load(path, &choices); err != nil {
+		return rewriteChoices{}, err
+	}
+	return choices, nil
+}
+
+func (c rewriteChoices) save() error {
+	path, err := rewriteChoicesPath()
+	if err != nil {
+		return err
+	}
+	return store.Save(path, c)
+}
+
+// rewriteCommand applies the matched rule's rewrite to the effective command
+// and puts it back behind its wrappers
+func rewriteCommand(detection monitor.Detection) ([]string, []string, bool) {
+	effective := detection.Rule.Apply(detection.Command.Effective)
+	return detection.Command.Replace(effective, detection.Rule.Rewrite.Env)
+}
+
+// canRewrite reports whether an interactive command has a non-interactive
+// form that can run in its place. Commands inside a "sh -c" script can't be
+// rewritten.
+func canRewrite(detection monitor.Detection) bool {
+	if !detection.Interactive || detection.Rule.Rewrite == nil {
+		return false
+	}
+	_, _, ok := rewriteCommand(detection)
+	return ok
+}
+
+// offerRewrite asks whether to run the non-interactive form of an interactive
+// command. It returns the argv and extra environment to run, and false if the
+// user wants the original command. Secrets are masked with redactor in what
+// is shown and in the remembered choices.
+func offerRewrite(reader *bufio.Reader, detection monitor.Detection, args []string, redactor *monitor.Redactor) ([]string, []string, bool) {
+	key := redactor.Redact(strings.Join(args, " "))
+	choices, err := loadRewriteChoices()
+	if err != nil {
+		fmt.Fprintf(console, "Warning: could not load remembered choices: %v\n", err)
+	}
+
+	rewritten, env, ok := rewriteCommand(detection)
+	if !ok {
+		return args, nil, false
+	}
+	display := monitor.QuoteArgs(rewritten)
+	if len(env) > 0 {
+		display = strings.Join(env, " ") + " " + display
+	}
+	display = redactor.Redact(display)
+
+	switch choices[key] {
+	case choiceAlways:
+		fmt.Fprintf(console, "Running non-interactive form: %s\n", display)
+		return rewritten, env, true
+	case choiceNever:
+		return args, nil, false
+	}
+
+	fmt.Fprintln(console, "\nThis command requires interactive input.")
+	fmt.Fprintln(console, "Run this non-interactive form instead?")
+	fmt.Fprintf(console, "\n  %s\n\n", display)
+	fmt.Fprintln(console, "[Y]es / [n]o / [a]lways / ne[v]er")
+	response, _ := reader.ReadString('\n')
+
+	switch strings.ToLower(strings.TrimSpace(response)) {
+	case "n", "no":
+		fmt.Fprintln(console, "Running the original command. Leave the game with ESC to answer its prompts.")
+		return args, nil, false
+	case "a", "always":
+		choices[key] = choiceAlways
+	case "v", "never":
+		choices[key] = choiceNever
+	default:
+		return rewritten, env, true
+	}
+
+	if err := choices.save(); err != nil {
+		fmt.Fprintf(console, "Warning: could not save choice: %v\n", err)
+	}
+	if choices[key] == choiceNever {
+		return args, nil, false
+	}
+	return rewritten, env, true
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/parth/DevTyper/config"
+	"github.com/parth/DevTyper/game"
+	"github.com/parth/DevTyper/store"
+)
+
+func textPositionsPath() (string, error) {
+	dir, err := config.DataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "text-positions.json"), nil
+}
+
+// Positions reached in custom texts, keyed by their source
+func loadTextPositions() (map[string]int, error) {
+	positions := map[string]int{}
+	path, err := textPositionsPath()
+	if err != nil {
+		return nil, err
+	}
+	if err := store.Load(path, &positions); err != nil {
+		return nil, err
+	}
+	return positions, nil
+}
+
+// promptInput returns where answers to our prompts and the task's are read
+// from. "-text -" uses up stdin, so they come from the terminal instead.
+func promptInput(textPath string) (*os.File, error) {
+	if textPath != "-" {
+		return os.Stdin, nil
+	}
+	tty, err := os.Open("/dev/tty")
+	if err != nil {
+		return nil, fmt.Errorf("-text - needs a terminal to answer prompts: %w", err)
+	}
+	return tty, nil
+}
+
+// Load the -text source and continue where the last session stopped
+func loadText(path string) (*game.TextGenerator, error) {
+	text, err := game.LoadText(path)
+	if err != nil {
+		return nil, err
+	}
+	positions, err := loadTextPositions()
+	if err != nil {
+		fmt.Fprintf(console, "Warning: could not load text positions: %v\n", err)
+	}
+	text.SetPosition(positions[text.Source])
+	return text, nil
+}
+
+func saveTextPosition(text *game.TextGenerator) error {
+	positions, err := loadTextPositions()
+	if err != nil {
+		return err
+	}
+	positions[text.Source] = text.Position()
+	path, err := textPositionsPath()
+	if err != nil {
+		return err
+	}
+	return store.Save(path, positions)
+}
@@ -0,0 +1,98 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+
+	"github.com/parth/DevTyper/monitor"
+)
+
+// Config holds user settings loaded from the devtyper config file.
+type Config struct {
+	Hooks  Hooks          `json:"hooks"`
+	Notify []string       `json:"notify"` // Built-in notifiers: notify-send, osc9, osc777, tmux
+	Redact Redact         `json:"redact"`
+	Rules  []monitor.Rule `json:"rules"` // Extra command detection rules
+	Typing Typing         `json:"typing"`
+}
+
+// Typing holds settings for the typing game
+type Typing struct {
+	Indent Indent `json:"indent"`
+}
+
+// Indent controls indentation in code practice snippets
+type Indent struct {
+	Typed bool `json:"typed"` // Require typing indentation instead of skipping it
+	Tabs  bool `json:"tabs"`  // Indent with tabs instead of spaces
+	Width int  `json:"width"` // Spaces per level, 4 when unset
+}
+
+// Hooks are shell commands run when a task finishes
+type Hooks struct {
+	OnComplete string `json:"on_complete"`
+	OnFailure  string `json:"on_failure"`
+}
+
+// Redact controls masking of secrets in task output
+type Redact struct {
+	Disable  bool     `json:"disable"`
+	Patterns []string `json:"patterns"` // Extra regular expressions to mask
+}
+
+// Path returns the default config file location
+func Path() string {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "devtyper", "config.json")
+}
+
+// DataDir returns the directory for devtyper's saved state, creating it if needed
+func DataDir() (string, error) {
+	dir := os.Getenv("XDG_DATA_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		dir = filepath.Join(home, ".local", "share")
+	}
+	dir = filepath.Join(dir, "devtyper")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	return dir, nil
+}
+
+// Load reads the config file at path. A missing file is not an error and
+// yields an empty config.
+func Load(path string) (*Config, error) {
+	cfg := &Config{}
+	if path == "" {
+		return cfg, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return nil, err
+	}
+	for i := range cfg.Rules {
+		cfg.Rules[i].Source = path
+	}
+	return cfg, nil
+}
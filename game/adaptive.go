@@ -0,0 +1,218 @@
+package game
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/parth/DevTyper/store"
+)
+
+// Pauses longer than this are thinking, not typing, and don't count as latency
+const maxKeyLatency = 2 * time.Second
+
+// Keys and bigrams need this many strokes before they can be called weak
+const minKeyStrokes = 10
+
+// KeyRecord is how one key or bigram has been typed so far
+type KeyRecord struct {
+	Strokes   int     `json:"strokes"`
+	Errors    int     `json:"errors"`
+	Timed     int     `json:"timed"`      // Strokes with a latency
+	LatencyMs float64 `json:"latency_ms"` // Summed over timed strokes
+}
+
+func (r *KeyRecord) add(correct bool, latency time.Duration) {
+	r.Strokes++
+	if !correct {
+		r.Errors++
+	}
+	if latency > 0 && latency < maxKeyLatency {
+		r.Timed++
+		r.LatencyMs += float64(latency.Milliseconds())
+	}
+}
+
+func (r *KeyRecord) averageLatency() float64 {
+	if r.Timed == 0 {
+		return 0
+	}
+	return r.LatencyMs / float64(r.Timed)
+}
+
+// KeyStats remembers error rates and latencies per key and bigram across
+// sessions. Keys are lowercase letters, digits and punctuation.
+type KeyStats struct {
+	path    string
+	Keys    map[string]*KeyRecord `json:"keys"`
+	Bigrams map[string]*KeyRecord `json:"bigrams"`
+}
+
+func NewKeyStats() *KeyStats {
+	return &KeyStats{Keys: map[string]*KeyRecord{}, Bigrams: map[string]*KeyRecord{}}
+}
+
+// LoadKeyStats reads the statistics file at path. A missing file yields
+// empty statistics.
+func LoadKeyStats(path string) (*KeyStats, error) {
+	ks := NewKeyStats()
+	ks.path = path
+	if err := store.Load(path, ks); err != nil {
+		return nil, err
+	}
+	if ks.Keys == nil {
+		ks.Keys = map[string]*KeyRecord{}
+	}
+	if ks.Bigrams == nil {
+		ks.Bigrams = map[string]*KeyRecord{}
+	}
+	return ks, nil
+}
+
+// Save writes the statistics back to their file
+func (ks *KeyStats) Save() error {
+	if ks.path == "" {
+		return fmt.Errorf("key statistics have no file")
+	}
+	return store.Save(ks.path, ks)
+}
+
+// Record a keystroke for the expected character, which followed prev
+func (ks *KeyStats) Record(prev, expected rune, correct bool, latency time.Duration) {
+	if unicode.IsSpace(expected) {
+		return
+	}
+	key := string(unicode.ToLower(expected))
+	if ks.Keys[key] == nil {
+		ks.Keys[key] = &KeyRecord{}
+	}
+	ks.Keys[key].add(correct, latency)
+
+	if unicode.IsLetter(prev) && unicode.IsLetter(expected) {
+		bigram := string(unicode.ToLower(prev)) + key
+		if ks.Bigrams[bigram] == nil {
+			ks.Bigrams[bigram] = &KeyRecord{}
+		}
+		ks.Bigrams[bigram].add(correct, latency)
+	}
+}
+
+// weakest returns up to n entries of records that are worse than average,
+// worst first. An entry scores its error rate, scaled by how much slower than
+// average it is typed.
+func weakest(records map[string]*KeyRecord, n int, allowed func(string) bool) []string {
+	var totalMs float64
+	var timed int
+	for _, r := range records {
+		totalMs += r.LatencyMs
+		timed += r.Timed
+	}
+	average := 0.0
+	if timed > 0 {
+		average = totalMs / float64(timed)
+	}
+
+	type scored struct {
+		key   string
+		score float64
+	}
+	var candidates []scored
+	var sum float64
+	for key, r := range records {
+		if r.Strokes < minKeyStrokes || !allowed(key) {
+			continue
+		}
+		score := float64(r.Errors+1) / float64(r.Strokes+10)
+		if average > 0 && r.Timed > 0 {
+			score *= r.averageLatency() / average
+		}
+		candidates = append(candidates, scored{key, score})
+		sum += score
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].score != candidates[j].score {
+			return candidates[i].score > candidates[j].score
+		}
+		return candidates[i].key < candidates[j].key
+	})
+
+	var keys []string
+	for _, c := range candidates[:min(n, len(candidates))] {
+		if c.score <= sum/float64(len(candidates)) {
+			break
+		}
+		keys = append(keys, c.key)
+	}
+	return keys
+}
+
+// AdaptiveGenerator favours words with the player's weakest keys and bigrams
+type AdaptiveGenerator struct {
+	stats     *KeyStats
+	words     []string
+	wordCount int
+	keys      []string // Targeted in the current text
+	bigrams   []string
+}
+
+func NewAdaptiveGenerator(stats *KeyStats) *AdaptiveGenerator {
+	return &AdaptiveGenerator{stats: stats, words: genericWords, wordCount: 25}
+}
+
+// Generate picks words weighted by how many weak keys and bigrams they hold.
+// The targets are worked out afresh for each text, so they follow the
+// player's progress.
+func (ag *AdaptiveGenerator) Generate() string {
+	inVocabulary := func(s string) bool {
+		for _, w := range ag.words {
+			if strings.Contains(w, s) {
+				return true
+			}
+		}
+		return false
+	}
+	ag.keys = weakest(ag.stats.Keys, 3, inVocabulary)
+	ag.bigrams = weakest(ag.stats.Bigrams, 3, inVocabulary)
+
+	weights := make([]int, len(ag.words))
+	total := 0
+	for i, w := range ag.words {
+		weights[i] = 1
+		for _, k := range ag.keys {
+			weights[i] += 3 * strings.Count(w, k)
+		}
+		for _, b := range ag.bigrams {
+			weights[i] += 5 * strings.Count(w, b)
+		}
+		total += weights[i]
+	}
+
+	words := make([]string, ag.wordCount)
+	for i := range words {
+		n := rand.Intn(total)
+		for j, weight := range weights {
+			if n < weight {
+				words[i] = ag.words[j]
+				break
+			}
+			n -= weight
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+func (ag *AdaptiveGenerator) SetWordCount(count int) {
+	ag.wordCount = count
+}
+
+// Attribution shows which keys and bigrams the text is targeting
+func (ag *AdaptiveGenerator) Attribution() string {
+	targets := append(append([]string{}, ag.keys...), ag.bigrams...)
+	if len(targets) == 0 {
+		return "Not enough keystrokes yet to find weak keys"
+	}
+	return "Targeting: " + strings.Join(targets, " ")
+}
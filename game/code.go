@@ -0,0 +1,115 @@
+package game
+
+import (
+	"math/rand"
+	"regexp"
+	"strings"
+
+	"github.com/parth/DevTyper/languages/golang"
+	"github.com/parth/DevTyper/languages/javascript"
+	"github.com/parth/DevTyper/languages/rust"
+)
+
+// codePack is one of the languages packages
+type codePack struct {
+	Name       string
+	Title      string
+	Templates  []string
+	Variables  []string
+	Types      []string
+	Operations []string
+	Values     map[string][]string
+}
+
+var codePacks = []codePack{
+	{"go", "Go", golang.Templates, golang.Variables, golang.Types, golang.Operations, golang.Values},
+	{"javascript", "JavaScript", javascript.Templates, javascript.Variables, javascript.Types, javascript.Operations, javascript.Values},
+	{"rust", "Rust", rust.Templates, rust.Variables, rust.Types, rust.Operations, rust.Values},
+}
+
+// codePackIndex returns the position of the named pack in codePacks, or 0
+func codePackIndex(name string) int {
+	for i, p := range codePacks {
+		if p.Name == name {
+			return i
+		}
+	}
+	return 0
+}
+
+var placeholderPattern = regexp.MustCompile(`\{(var|Var|type|value)\}`)
+
+// Deepest block nesting in a generated snippet
+const maxCodeDepth = 3
+
+// CodeGenerator produces snippets of code from a language pack's templates
+type CodeGenerator struct {
+	pack      codePack
+	indent    string // One level of indentation
+	wordCount int
+}
+
+func NewCodeGenerator(language string, indent IndentOptions) *CodeGenerator {
+	return &CodeGenerator{
+		pack:      codePacks[codePackIndex(language)],
+		indent:    indent.unit(),
+		wordCount: 25,
+	}
+}
+
+// Generate fills roughly one template per five words into a multi-line
+// snippet. Templates ending in "{" open a block that is indented and closed
+// again further down.
+func (cg *CodeGenerator) Generate() string {
+	count := max(1, cg.wordCount/5)
+	var lines []string
+	depth := 0
+	for i := 0; i < count; i++ {
+		// Close a block now and then, and always before nesting too deep
+		for depth > 0 && (depth >= maxCodeDepth || rand.Intn(3) == 0) {
+			depth--
+			lines = append(lines, strings.Repeat(cg.indent, depth)+"}")
+		}
+		line := cg.fill(cg.pack.Templates[rand.Intn(len(cg.pack.Templates))])
+		lines = append(lines, strings.Repeat(cg.indent, depth)+line)
+		if strings.HasSuffix(line, "{") {
+			depth++
+		}
+	}
+	for depth > 0 {
+		depth--
+		lines = append(lines, strings.Repeat(cg.indent, depth)+"}")
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (cg *CodeGenerator) SetWordCount(count int) {
+	cg.wordCount = count
+}
+
+// fill replaces the placeholders of tmpl. Values follow the last type placed
+// so declarations like "var x int = 0" stay consistent.
+func (cg *CodeGenerator) fill(tmpl string) string {
+	lastType := ""
+	return placeholderPattern.ReplaceAllStringFunc(tmpl, func(ph string) string {
+		switch ph {
+		case "{var}":
+			return pick(cg.pack.Variables)
+		case "{Var}":
+			v := pick(cg.pack.Variables)
+			return strings.ToUpper(v[:1]) + v[1:]
+		case "{type}":
+			lastType = pick(cg.pack.Types)
+			return lastType
+		default:
+			if values, ok := cg.pack.Values[lastType]; ok {
+				return pick(values)
+			}
+			return pick(cg.pack.Operations)
+		}
+	})
+}
+
+func pick(words []string) string {
+	return words[rand.Intn(len(words))]
+}
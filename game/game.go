@@ -2,8 +2,11 @@ package game
 
 import (
 	"fmt"
+	"io"
+	"os"
 	"strings"
 	"time"
+	"unicode"
 
 	"github.com/gdamore/tcell/v2"
 	"github.com/parth/DevTyper/monitor"
@@ -18,19 +21,58 @@ const (
 	StateResults
 	StateTaskComplete
 	StateError
+	StateLanguageSelect
+	StateQuoteSelect
 )
 
+type Mode int
+
+const (
+	ModeWords Mode = iota
+	ModeCode
+	ModeWait
+	ModeText
+	ModeQuote
+	ModeAdaptive
+	ModeShell
+	ModeOutput
+	ModeZen
+)
+
+var modeNames = map[Mode]string{
+	ModeWords:    "Practice Typing",
+	ModeCode:     "Practice Code",
+	ModeWait:     "Wait for Task",
+	ModeText:     "Practice Custom Text",
+	ModeQuote:    "Practice Quotes",
+	ModeAdaptive: "Practice Weak Keys",
+	ModeShell:    "Practice Shell Commands",
+	ModeOutput:   "Type Along with Output",
+	ModeZen:      "Zen Mode",
+}
+
+func (m Mode) String() string {
+	return modeNames[m]
+}
+
 type CharacterState struct {
 	char    rune
 	correct bool
 	typed   bool
+	auto    bool // Indentation filled in for the player
+	missed  bool // A wrong key was refused here
 }
 
 type Stats struct {
-	startTime    time.Time
-	wordsTyped   int
-	totalStrokes int
-	errorStrokes int
+	startTime     time.Time
+	endTime       time.Time // Set when the round is over
+	wordsTyped    int
+	totalStrokes  int
+	errorStrokes  int
+	letterStrokes int
+	letterErrors  int
+	symbolStrokes int // Digits and punctuation
+	symbolErrors  int
 }
 
 func NewStats() *Stats {
@@ -39,8 +81,16 @@ func NewStats() *Stats {
 	}
 }
 
+// elapsed returns the time spent in the round so far
+func (s *Stats) elapsed() time.Duration {
+	if !s.endTime.IsZero() {
+		return s.endTime.Sub(s.startTime)
+	}
+	return time.Since(s.startTime)
+}
+
 func (s *Stats) calculateWPM() float64 {
-	elapsedMinutes := time.Since(s.startTime).Minutes()
+	elapsedMinutes := s.elapsed().Minutes()
 	if elapsedMinutes == 0 {
 		return 0
 	}
@@ -54,39 +104,99 @@ func (s *Stats) calculateAccuracy() float64 {
 	return float64(s.totalStrokes-s.errorStrokes) / float64(s.totalStrokes) * 100
 }
 
+// Tally a keystroke against the letter or symbol counters
+func (s *Stats) recordChar(expected rune, correct bool) {
+	switch {
+	case unicode.IsLetter(expected):
+		s.letterStrokes++
+		if !correct {
+			s.letterErrors++
+		}
+	case !unicode.IsSpace(expected):
+		s.symbolStrokes++
+		if !correct {
+			s.symbolErrors++
+		}
+	}
+}
+
+func accuracy(strokes, errors int) float64 {
+	if strokes == 0 {
+		return 100
+	}
+	return float64(strokes-errors) / float64(strokes) * 100
+}
+
 type Results struct {
-	Duration    int
-	WPM         float64
-	Accuracy    float64
-	WordsTyped  int
-	TotalErrors int
+	Duration    int     `json:"duration_seconds"`
+	WPM         float64 `json:"wpm"`
+	Accuracy    float64 `json:"accuracy"`
+	WordsTyped  int     `json:"words_typed"`
+	TotalErrors int     `json:"total_errors"`
+
+	LetterAccuracy float64 `json:"letter_accuracy"`
+	SymbolAccuracy float64 `json:"symbol_accuracy"`
+
+	Rules       string   `json:"rules,omitempty"`        // Ruleset the round was typed under, e.g. "confidence"
+	Modifiers   []string `json:"modifiers,omitempty"`    // Word modifiers in use, e.g. "punctuation"
+	OutputLines []string `json:"output_lines,omitempty"` // Lines typed from the task's output
+
+	RhythmMs        float64 `json:"rhythm_ms,omitempty"`        // Average gap between keystrokes in zen mode
+	RhythmVariation float64 `json:"rhythm_variation,omitempty"` // How much the gaps vary, in percent
 }
 
 type Game struct {
 	screen           tcell.Screen
 	sentenceGen      *SentenceGenerator
+	text             *TextGenerator // Player's own text, if any
+	shell            *ShellGenerator
+	output           *OutputGenerator
+	outputLines      []string // Output lines typed this round
+	quotes           *QuoteGenerator
+	QuoteHistory     *QuoteHistory
+	KeyStats         *KeyStats // Errors and latencies per key, across sessions
+	lastStroke       time.Time
+	keyGaps          []time.Duration // Between keystrokes of a zen round
+	zenSaved         bool
+	rules            Ruleset
+	ZenDir           string    // Where zen texts are saved
+	selectedTier     int       // Index into quoteTiers
+	quoteBest        bool      // The last quote was typed in the best time yet
+	generator        Generator // Source of text for the current mode
 	currentSentence  string
-	userInput        string
+	userInput        []rune
 	isRunning        bool
 	stats            *Stats
 	state            GameState
-	wordCountOptions []int
-	selectedCount    int
+	roundOptions     []Round
+	selectedRound    int
+	round            Round
+	selectedResult   int // Index into resultChoices
 	currentChars     []CharacterState
 	results          *Results
 	taskDone         chan bool
 	ForceExit        bool
+	Terminal         io.Writer // Where the cursor is restored on exit, stdout by default
+	Language         string    // Preselected languages pack, e.g. "go"
+	languageSource   string    // What Language was picked from, e.g. "cargo" or "project"
+	Indent           IndentOptions
 	taskDescription  string
+	commandType      monitor.CommandType
 	task             *monitor.Task
-	modeOptions      []string
+	modeOptions      []Mode
 	selectedMode     int
+	mode             Mode
+	selectedLanguage int // Index into codePacks, then again for repository snippets
+	notice           string
 	cursorX          int
 	cursorY          int
 	lastOutput       []string
 	outputStartRow   int
+	taskWaiting      bool // Task was waiting for input at the last draw
+	cleanedUp        bool
 }
 
-func New(taskDone chan bool, description string, task *monitor.Task) (*Game, error) {
+func New(taskDone chan bool, description string, task *monitor.Task, commandType monitor.CommandType, project monitor.Project) (*Game, error) {
 	screen, err := tcell.NewScreen()
 	if err != nil {
 		return nil, err
@@ -97,36 +207,69 @@ func New(taskDone chan bool, description string, task *monitor.Task) (*Game, err
 	}
 
 	sentenceGen := NewSentenceGenerator()
+	quotes, err := NewQuoteGenerator()
+	if err != nil {
+		return nil, err
+	}
 
 	game := &Game{
-		screen:           screen,
-		sentenceGen:      sentenceGen,
-		isRunning:        true,
-		stats:            NewStats(),
-		state:            StateMode,
-		wordCountOptions: []int{10, 25, 50, 100},
-		selectedCount:    0,
-		currentChars:     make([]CharacterState, 0),
-		results:          &Results{},
-		taskDone:         taskDone,
-		ForceExit:        false,
-		taskDescription:  description,
-		task:             task,
-		modeOptions:      []string{"Practice Typing", "Wait for Task"},
-		selectedMode:     0,
-		cursorX:           7,
-		cursorY:           3,
-		lastOutput:       []string{},
-		outputStartRow:   0,
+		screen:          screen,
+		sentenceGen:     sentenceGen,
+		quotes:          quotes,
+		QuoteHistory:    &QuoteHistory{Quotes: map[int]*QuoteRecord{}},
+		KeyStats:        NewKeyStats(),
+		ZenDir:          ".",
+		generator:       sentenceGen,
+		isRunning:       true,
+		stats:           NewStats(),
+		state:           StateMode,
+		roundOptions:    defaultRounds,
+		selectedRound:   0,
+		currentChars:    make([]CharacterState, 0),
+		results:         &Results{},
+		taskDone:        taskDone,
+		ForceExit:       false,
+		Terminal:        os.Stdout,
+		taskDescription: description,
+		commandType:     commandType,
+		task:            task,
+		modeOptions:     []Mode{ModeWords, ModeAdaptive, ModeCode, ModeOutput, ModeQuote, ModeZen, ModeWait},
+		selectedMode:    0,
+		Indent:          DefaultIndentOptions(),
+		cursorX:         7,
+		cursorY:         3,
+		lastOutput:      []string{},
+		outputStartRow:  0,
 	}
+	game.Language, game.languageSource = monitor.TaskLanguage(commandType, project)
 	return game, nil
 }
 
+// SetText offers practice on the player's own text, as the first mode
+func (g *Game) SetText(text *TextGenerator) {
+	g.text = text
+	g.modeOptions = append([]Mode{ModeText}, g.modeOptions...)
+}
+
+// SetShellHistory offers practice on shell commands, those of the running
+// task's tools first
+func (g *Game) SetShellHistory(shell *ShellGenerator) {
+	shell.SetTools(monitor.Tools(g.commandType))
+	g.shell = shell
+	for i, mode := range g.modeOptions {
+		if mode == ModeCode {
+			g.modeOptions = append(g.modeOptions[:i+1], append([]Mode{ModeShell}, g.modeOptions[i+1:]...)...)
+			break
+		}
+	}
+}
+
 func (g *Game) updateCurrentChars() {
-	g.currentChars = make([]CharacterState, len(g.currentSentence))
-	for i, c := range g.currentSentence {
-		g.currentChars[i] = CharacterState{char: c, correct: false, typed: false}
+	g.currentChars = make([]CharacterState, 0, len(g.currentSentence))
+	for _, c := range g.currentSentence {
+		g.currentChars = append(g.currentChars, CharacterState{char: c})
 	}
+	g.userInput = g.userInput[:0]
 }
 
 func (g *Game) updateCommandOutput() {
@@ -138,11 +281,42 @@ func (g *Game) updateCommandOutput() {
 	g.lastOutput = g.task.GetRecentOutput(5)
 }
 
+// Wake the event loop regularly so task output and status stay current
+// even while the player is not typing
+func (g *Game) tick(stop <-chan struct{}) {
+	ticker := time.NewTicker(250 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			g.screen.PostEvent(tcell.NewEventInterrupt(nil))
+		}
+	}
+}
+
 func (g *Game) Run() {
+	stopTick := make(chan struct{})
+	defer close(stopTick)
+	go g.tick(stopTick)
+
 gameLoop:
 	for g.isRunning {
 		select {
 		case <-g.taskDone:
+			// A round lasting until the task finishes ends with its results,
+			// which may already be showing if the tick noticed first
+			if g.round.UntilTask && (g.state == StatePlaying || g.state == StateResults) {
+				if g.state == StatePlaying {
+					g.endRound()
+				}
+				if g.ForceExit {
+					break gameLoop
+				}
+				g.draw()
+				continue
+			}
 			if g.ForceExit || g.task.IsComplete() {
 				g.showTaskComplete()
 				break gameLoop
@@ -154,8 +328,16 @@ gameLoop:
 				g.handleModeSelect()
 			case StateWordCountSelect:
 				g.handleWordCountSelect()
+			case StateLanguageSelect:
+				g.handleLanguageSelect()
+			case StateQuoteSelect:
+				g.handleQuoteSelect()
 			case StatePlaying:
-				g.handleInput()
+				if g.round.Zen {
+					g.handleZenInput()
+				} else {
+					g.handleInput()
+				}
 			case StateResults:
 				g.handleResults()
 			case StateError:
@@ -163,7 +345,9 @@ gameLoop:
 			case StateTaskComplete:
 				g.draw()
 			}
-			g.draw()
+			if g.isRunning { // The screen is gone once the game has stopped
+				g.draw()
+			}
 		}
 	}
 
@@ -203,18 +387,96 @@ func (g *Game) handleModeSelect() {
 		switch ev.Key() {
 		case tcell.KeyEscape:
 			g.isRunning = false
-		case tcell.KeyUp, tcell.KeyDown:
+		case tcell.KeyUp:
+			g.selectedMode = (g.selectedMode - 1 + len(g.modeOptions)) % len(g.modeOptions)
+		case tcell.KeyDown:
 			g.selectedMode = (g.selectedMode + 1) % len(g.modeOptions)
+		case tcell.KeyRune:
+			if ev.Rune() == 'r' {
+				g.rules = g.rules.next()
+			}
 		case tcell.KeyEnter:
-			if g.selectedMode == 0 {
+			g.mode = g.modeOptions[g.selectedMode]
+			switch g.mode {
+			case ModeWords:
+				g.generator = g.sentenceGen
 				g.state = StateWordCountSelect
-			} else {
+			case ModeAdaptive:
+				g.generator = NewAdaptiveGenerator(g.KeyStats)
+				g.state = StateWordCountSelect
+			case ModeShell:
+				g.generator = g.shell
+				g.state = StateWordCountSelect
+			case ModeOutput:
+				if g.output == nil {
+					g.output = NewOutputGenerator(g.task, g.sentenceGen)
+				}
+				g.generator = g.output
+				g.state = StateWordCountSelect
+			case ModeCode:
+				g.selectedLanguage = codePackIndex(g.Language)
+				g.state = StateLanguageSelect
+			case ModeText:
+				g.generator = g.text
+				g.state = StateWordCountSelect
+			case ModeQuote:
+				g.generator = g.quotes
+				g.state = StateQuoteSelect
+			case ModeZen:
+				g.startRound(zenRound)
+			case ModeWait:
 				g.isRunning = false // Just wait for task
 			}
 		}
 	}
 }
 
+func (g *Game) handleLanguageSelect() {
+	ev := g.screen.PollEvent()
+	switch ev := ev.(type) {
+	case *tcell.EventKey:
+		switch ev.Key() {
+		case tcell.KeyEscape:
+			g.state = StateMode
+		case tcell.KeyUp:
+			g.selectedLanguage = (g.selectedLanguage - 1 + 2*len(codePacks)) % (2 * len(codePacks))
+		case tcell.KeyDown:
+			g.selectedLanguage = (g.selectedLanguage + 1) % (2 * len(codePacks))
+		case tcell.KeyEnter:
+			// The second half of the list takes snippets from the repository
+			pack := codePacks[g.selectedLanguage%len(codePacks)]
+			g.notice = ""
+			if g.selectedLanguage < len(codePacks) {
+				g.generator = NewCodeGenerator(pack.Name, g.Indent)
+			} else if repo, err := NewRepoGenerator(repoRoot(), pack.Name); err != nil {
+				g.notice = err.Error()
+				return
+			} else {
+				g.generator = repo
+			}
+			g.state = StateWordCountSelect
+		}
+	}
+}
+
+func (g *Game) handleQuoteSelect() {
+	ev := g.screen.PollEvent()
+	switch ev := ev.(type) {
+	case *tcell.EventKey:
+		switch ev.Key() {
+		case tcell.KeyEscape:
+			g.state = StateMode
+		case tcell.KeyUp:
+			g.selectedTier = (g.selectedTier - 1 + len(quoteTiers)) % len(quoteTiers)
+		case tcell.KeyDown:
+			g.selectedTier = (g.selectedTier + 1) % len(quoteTiers)
+		case tcell.KeyEnter:
+			g.quotes.SetTier(quoteTiers[g.selectedTier])
+			g.startRound(Round{Quote: true})
+		}
+	}
+}
+
 func (g *Game) handleWordCountSelect() {
 	ev := g.screen.PollEvent()
 	switch ev := ev.(type) {
@@ -223,20 +485,43 @@ func (g *Game) handleWordCountSelect() {
 		case tcell.KeyEscape:
 			g.state = StateMode
 		case tcell.KeyUp:
-			g.selectedCount = (g.selectedCount - 1 + len(g.wordCountOptions)) % len(g.wordCountOptions)
+			g.selectedRound = (g.selectedRound - 1 + len(g.roundOptions)) % len(g.roundOptions)
 		case tcell.KeyDown:
-			g.selectedCount = (g.selectedCount + 1) % len(g.wordCountOptions)
+			g.selectedRound = (g.selectedRound + 1) % len(g.roundOptions)
 		case tcell.KeyEnter:
-			g.sentenceGen.SetWordCount(g.wordCountOptions[g.selectedCount])
-			g.currentSentence = g.sentenceGen.Generate()
-			g.updateCurrentChars()
-			g.state = StatePlaying
+			g.startRound(g.roundOptions[g.selectedRound])
+		case tcell.KeyRune:
+			if g.mode == ModeWords {
+				g.toggleModifier(ev.Rune())
+			}
+			if g.mode == ModeShell && ev.Rune() == 't' && g.shell.toolCommands() > 0 {
+				g.shell.Restricted = !g.shell.Restricted
+			}
 		}
 	}
 }
 
+// toggleModifier switches the word modifier bound to key
+func (g *Game) toggleModifier(key rune) {
+	m := &g.sentenceGen.Modifiers
+	switch key {
+	case 'c':
+		m.Capitals = !m.Capitals
+	case 'p':
+		m.Punctuation = !m.Punctuation
+	case 'q':
+		m.Quotes = !m.Quotes
+	case 'n':
+		m.Numbers = !m.Numbers
+	}
+}
+
 func (g *Game) handleInput() {
 	ev := g.screen.PollEvent()
+	if g.roundOver() {
+		g.endRound()
+		return
+	}
 	switch ev := ev.(type) {
 	case *tcell.EventKey:
 		switch ev.Key() {
@@ -245,53 +530,107 @@ func (g *Game) handleInput() {
 			g.isRunning = false
 			return
 		case tcell.KeyRune:
-			if len(g.userInput) < len(g.currentSentence) {
-				g.userInput += string(ev.Rune())
-				g.stats.totalStrokes++
-				pos := len(g.userInput) - 1
-				g.currentChars[pos].typed = true
-				g.currentChars[pos].correct = g.userInput[pos] == g.currentSentence[pos]
-				if !g.currentChars[pos].correct {
-					g.stats.errorStrokes++
-				}
-			}
+			g.typeRune(ev.Rune())
+		case tcell.KeyTab:
+			g.typeTab()
 		case tcell.KeyEnter:
-			g.checkWord()
-		case tcell.KeyBackspace, tcell.KeyBackspace2:
-			if len(g.userInput) > 0 {
-				pos := len(g.userInput) - 1
-				g.currentChars[pos].typed = false
-				g.currentChars[pos].correct = false
-				g.userInput = g.userInput[:pos]
+			// In a snippet Enter is a newline until the end is reached
+			if g.isMultiline() && len(g.userInput) < len(g.currentChars) {
+				g.typeRune('\n')
+			} else {
+				g.checkWord()
 			}
+		case tcell.KeyBackspace, tcell.KeyBackspace2:
+			g.backspace()
 		}
 	}
 }
 
 func (g *Game) checkWord() {
-	if g.userInput == g.currentSentence {
-		g.stats.wordsTyped += len(strings.Fields(g.currentSentence))
-		g.currentSentence = g.sentenceGen.Generate()
-		g.userInput = ""
+	exact := string(g.userInput) == g.currentSentence
+	// Without backspace a text with mistakes is done once it is all typed
+	if exact || (g.rules == RulesConfidence && len(g.userInput) == len(g.currentChars)) {
+		if exact {
+			g.stats.wordsTyped += len(strings.Fields(g.currentSentence))
+		} else {
+			g.stats.wordsTyped += g.partialWords()
+		}
+		if g.mode == ModeText {
+			g.text.Finished()
+		}
+		if g.mode == ModeOutput && g.output.FromOutput() {
+			g.outputLines = append(g.outputLines, strings.Split(g.currentSentence, "\n")...)
+		}
+		if g.round.endsWithText() {
+			g.endRound()
+			return
+		}
+		g.currentSentence = g.generator.Generate()
 		g.updateCurrentChars()
 	}
 }
 
 func (g *Game) saveResults() {
 	g.results = &Results{
+		Duration:    int(g.stats.elapsed().Round(time.Second).Seconds()),
 		WPM:         g.stats.calculateWPM(),
 		Accuracy:    g.stats.calculateAccuracy(),
 		WordsTyped:  g.stats.wordsTyped,
 		TotalErrors: g.stats.errorStrokes,
+
+		LetterAccuracy: accuracy(g.stats.letterStrokes, g.stats.letterErrors),
+		SymbolAccuracy: accuracy(g.stats.symbolStrokes, g.stats.symbolErrors),
+	}
+	if g.mode == ModeWords {
+		g.results.Modifiers = g.sentenceGen.Modifiers.Names()
+	}
+	if g.mode == ModeOutput {
+		g.results.OutputLines = g.outputLines
+	}
+	if g.round.Zen {
+		g.results.RhythmMs, g.results.RhythmVariation = g.rhythm()
+	} else {
+		g.results.Rules = g.rules.String()
 	}
 }
 
+// Results returns the statistics of the typing session
+func (g *Game) Results() Results {
+	return *g.results
+}
+
 func (g *Game) handleResults() {
 	ev := g.screen.PollEvent()
 	switch ev := ev.(type) {
 	case *tcell.EventKey:
+		choices := g.resultChoices()
+		g.selectedResult = min(g.selectedResult, len(choices)-1) // The task may have finished meanwhile
 		switch ev.Key() {
-		case tcell.KeyEscape, tcell.KeyEnter:
+		case tcell.KeyUp:
+			g.selectedResult = (g.selectedResult - 1 + len(choices)) % len(choices)
+		case tcell.KeyDown:
+			g.selectedResult = (g.selectedResult + 1) % len(choices)
+		case tcell.KeyEnter:
+			switch choices[g.selectedResult] {
+			case choiceRetryQuote:
+				g.quotes.Retry()
+				g.startRound(g.round)
+			case choicePlayAgain, choiceNextQuote:
+				g.startRound(g.round)
+			case choiceSaveText:
+				if path, err := g.saveZenText(); err != nil {
+					g.notice = "Could not save the text: " + err.Error()
+				} else {
+					g.notice = "Saved to " + path
+					g.zenSaved = true
+				}
+			case choiceChangeMode:
+				g.state = StateMode
+			default:
+				g.Cleanup()
+				g.isRunning = false
+			}
+		case tcell.KeyEscape:
 			g.Cleanup()
 			g.isRunning = false
 		}
@@ -299,13 +638,17 @@ func (g *Game) handleResults() {
 }
 
 func (g *Game) Cleanup() {
+	if g.cleanedUp {
+		return // Touching the screen after Fini hangs
+	}
+	g.cleanedUp = true
 	g.saveResults()
 	g.screen.Clear()
 	g.screen.Sync()
 	g.screen.Fini()
 	// Reset terminal state
-	fmt.Print("\033[?25h") // Show cursor
-	fmt.Print("\033[2J\033[H") // Clear screen
+	fmt.Fprint(g.Terminal, "\033[?25h")     // Show cursor
+	fmt.Fprint(g.Terminal, "\033[2J\033[H") // Clear screen
 }
 
 func (g *Game) ShowError(message string) {
@@ -363,25 +706,108 @@ func (g *Game) draw() {
 			if i == g.selectedMode {
 				modeStyle = modeStyle.Background(tcell.ColorBlue)
 			}
-			drawText(g.screen, 3, 5+i, modeStyle, mode)
+			label := mode.String()
+			if mode == ModeText {
+				label += fmt.Sprintf(" (%.0f%% through)", g.text.Progress())
+			}
+			drawText(g.screen, 3, 5+i, modeStyle, label)
+		}
+		drawText(g.screen, 1, 6+len(g.modeOptions), style, "(r) Rules: "+g.rules.String())
+
+	case StateLanguageSelect:
+		drawText(g.screen, 1, 1, style.Bold(true), "DevTyper - Select Language")
+		drawText(g.screen, 1, 3, style, "Use Up/Down arrows to select, Enter to confirm:")
+
+		for i := 0; i < 2*len(codePacks); i++ {
+			pack := codePacks[i%len(codePacks)]
+			langStyle := style
+			if i == g.selectedLanguage {
+				langStyle = langStyle.Background(tcell.ColorBlue)
+			}
+			label := pack.Title
+			if i >= len(codePacks) {
+				label += " from this repository"
+			} else if pack.Name == g.Language {
+				label += " (" + g.languageSource + ")"
+			}
+			drawText(g.screen, 3, 5+i, langStyle, label)
+		}
+		y := 6 + 2*len(codePacks)
+		if g.Language != "" {
+			drawText(g.screen, 1, y, style,
+				fmt.Sprintf("Preselected from %s; pick another language to override", g.languageSource))
+			y++
+		}
+		if g.notice != "" {
+			drawText(g.screen, 1, y, style.Foreground(tcell.ColorRed), g.notice)
+		}
+
+	case StateQuoteSelect:
+		drawText(g.screen, 1, 1, style.Bold(true), "DevTyper - Select Quote Length")
+		drawText(g.screen, 1, 3, style, "Use Up/Down arrows to select, Enter to confirm:")
+
+		for i, tier := range quoteTiers {
+			tierStyle := style
+			if i == g.selectedTier {
+				tierStyle = tierStyle.Background(tcell.ColorBlue)
+			}
+			drawText(g.screen, 3, 5+i, tierStyle, fmt.Sprintf("%s (%d quotes)", tier, g.quotes.Count(tier)))
 		}
 
 	case StateWordCountSelect:
-		drawText(g.screen, 1, 1, style.Bold(true), "DevTyper - Select Word Count")
+		drawText(g.screen, 1, 1, style.Bold(true), "DevTyper - Select Round")
 		drawText(g.screen, 1, 3, style, "Use Up/Down arrows to select, Enter to confirm:")
 
-		for i, count := range g.wordCountOptions {
-			countStyle := style
-			if i == g.selectedCount {
-				countStyle = countStyle.Background(tcell.ColorBlue)
+		for i, round := range g.roundOptions {
+			roundStyle := style
+			if i == g.selectedRound {
+				roundStyle = roundStyle.Background(tcell.ColorBlue)
 			}
-			drawText(g.screen, 3, 5+i, countStyle, fmt.Sprintf("%d words", count))
+			drawText(g.screen, 3, 5+i, roundStyle, round.String())
+		}
+
+		if g.mode == ModeWords {
+			m := g.sentenceGen.Modifiers
+			y := 6 + len(g.roundOptions)
+			drawText(g.screen, 1, y, style, "Modifiers, toggled with their key:")
+			for i, mod := range []struct {
+				on    bool
+				label string
+			}{
+				{m.Capitals, "(c) Capitals"},
+				{m.Punctuation, "(p) Punctuation"},
+				{m.Quotes, "(q) Quotes and parentheses"},
+				{m.Numbers, "(n) Numbers"},
+			} {
+				box := "[ ]"
+				if mod.on {
+					box = "[x]"
+				}
+				drawText(g.screen, 3, y+1+i, style, box+" "+mod.label)
+			}
+		}
+		if g.mode == ModeShell && g.shell.toolCommands() > 0 {
+			box := "[ ]"
+			if g.shell.Restricted {
+				box = "[x]"
+			}
+			drawText(g.screen, 1, 6+len(g.roundOptions), style,
+				fmt.Sprintf("%s (t) Only %s commands", box, strings.Join(g.shell.tools, ", ")))
 		}
 
 	case StatePlaying:
 		// Draw header with border
 		drawBorder(g.screen, 0, 0, width-1, 2, style)
 		drawText(g.screen, 2, 1, style.Bold(true), "DevTyper - Typing Practice")
+		if g.round.Zen {
+			drawText(g.screen, 30, 1, style.Foreground(tcell.ColorYellow), "Zen - type anything, no target")
+		} else if a, ok := g.generator.(attributed); ok {
+			attribution := []rune(a.Attribution())
+			if room := width - 32; len(attribution) > room {
+				attribution = attribution[:max(0, room)]
+			}
+			drawText(g.screen, 30, 1, style.Foreground(tcell.ColorYellow), string(attribution))
+		}
 
 		// Calculate how much space we have for text area
 		textAreaHeight := availableHeight - 8 // Reserve space for stats box
@@ -389,69 +815,114 @@ func (g *Game) draw() {
 		// Draw text area with border
 		drawBorder(g.screen, 0, 2, width-1, textAreaHeight+2, style)
 
-		// Draw sentence with wrapping and cursor
-		maxWidth := width - 8
-		wrappedSentence := wrapText(g.currentSentence, maxWidth)
-		currentPos := len(g.userInput)
-
+		// Draw the text keeping its lines and indentation
 		drawText(g.screen, 2, 3, style, "Type:")
-
-		for i, line := range wrappedSentence {
-			x := 7
-			lineStart := 0
-			if i > 0 {
-				for _, prevLine := range wrappedSentence[:i] {
-					lineStart += len(prevLine)
-				}
-			}
-
-			// Draw each character in the line
-			for j, char := range line {
-				pos := lineStart + j
-				charStyle := style
-
-				if pos == currentPos {
-					g.cursorX = x + j
-					g.cursorY = 3 + i
-					charStyle = charStyle.Background(tcell.ColorWhite).Foreground(tcell.ColorBlack)
-				} else if pos < len(g.currentChars) {
-					cs := g.currentChars[pos]
-					if cs.typed {
-						if cs.correct {
-							charStyle = charStyle.Foreground(tcell.ColorGreen)
-						} else {
-							charStyle = charStyle.Foreground(tcell.ColorRed)
-						}
-					}
-				}
-				drawText(g.screen, x+j, 3+i, charStyle, string(char))
-			}
-
-			// Show cursor at end of input if needed
-			if currentPos == len(g.currentSentence) && i == len(wrappedSentence)-1 {
-				g.cursorX = x + len(line)
-				g.cursorY = 3 + i
-			}
-		}
+		g.drawTarget(7, 3, width-8, textAreaHeight-1, style)
 
 		// Draw stats box
 		statsY := textAreaHeight + 4
 		drawBorder(g.screen, 0, statsY-1, width-1, statsY+3, style)
-		drawText(g.screen, 2, statsY, style, fmt.Sprintf("WPM: %.1f | Accuracy: %.1f%% | Words: %d",
+		statsLine := fmt.Sprintf("WPM: %.1f | Accuracy: %.1f%% | Words: %d",
 			g.stats.calculateWPM(),
 			g.stats.calculateAccuracy(),
-			g.stats.wordsTyped))
-		drawText(g.screen, 2, statsY+2, style, "Press ESC to exit")
+			g.stats.wordsTyped)
+		if g.round.Zen {
+			statsLine = fmt.Sprintf("WPM: %.1f | Words: %d", g.stats.calculateWPM(), g.stats.wordsTyped)
+		}
+		if g.rules != RulesNormal && !g.round.Zen {
+			statsLine += " | Rules: " + g.rules.String()
+		}
+		if g.mode == ModeCode {
+			statsLine += fmt.Sprintf(" | Letters: %.1f%% | Symbols: %.1f%%",
+				accuracy(g.stats.letterStrokes, g.stats.letterErrors),
+				accuracy(g.stats.symbolStrokes, g.stats.symbolErrors))
+		}
+		if g.round.timed() {
+			statsLine += " | Time left: " + formatClock(g.timeLeft())
+		}
+		if g.round.UntilTask && g.task != nil {
+			statsLine += " | Task running: " + formatClock(g.task.Duration())
+		}
+		drawText(g.screen, 2, statsY, style, statsLine)
+		if g.round.Zen {
+			drawText(g.screen, 2, statsY+2, style, "Press ESC to finish")
+		} else {
+			drawText(g.screen, 2, statsY+2, style, "Press ESC to exit")
+		}
 
 		// Set the starting row for command output
 		g.outputStartRow = statsY + 5
 
 	case StateResults:
-		drawText(g.screen, 1, 1, style.Bold(true), "Time's Up! - Final Results")
+		title := "Round Complete - Final Results"
+		if g.round.Zen {
+			title = "Zen - Final Results"
+		} else if g.round.timed() {
+			title = "Time's Up! - Final Results"
+		} else if g.round.UntilTask {
+			title = "Task Finished - Final Results"
+		}
+		drawText(g.screen, 1, 1, style.Bold(true), title)
 		drawText(g.screen, 1, 3, style, fmt.Sprintf("Words per minute: %.1f", g.stats.calculateWPM()))
 		drawText(g.screen, 1, 4, style, fmt.Sprintf("Accuracy: %.1f%%", g.stats.calculateAccuracy()))
 		drawText(g.screen, 1, 5, style, fmt.Sprintf("Total words typed: %d", g.stats.wordsTyped))
-		drawText(g.screen, 1, 7, style, "Press Enter/ESC to exit")
+		drawText(g.screen, 1, 6, style, fmt.Sprintf("Letter accuracy: %.1f%% | Symbol accuracy: %.1f%%",
+			accuracy(g.stats.letterStrokes, g.stats.letterErrors),
+			accuracy(g.stats.symbolStrokes, g.stats.symbolErrors)))
+		drawText(g.screen, 1, 7, style, fmt.Sprintf("Time: %s (%s)", g.stats.elapsed().Round(time.Second), g.round))
+		y := 8
+		if len(g.results.Modifiers) > 0 {
+			drawText(g.screen, 1, y, style, "Modifiers: "+strings.Join(g.results.Modifiers, ", "))
+			y++
+		}
+		if g.results.Rules != "" && g.results.Rules != RulesNormal.String() {
+			drawText(g.screen, 1, y, style, "Rules: "+g.results.Rules)
+			y++
+		}
+		y++
+		if g.round.Quote {
+			drawText(g.screen, 1, y, style, g.quotes.Attribution())
+			if r := g.QuoteHistory.Best(g.quotes.Current().ID, g.rules); r != nil {
+				best := fmt.Sprintf("Best: %.1fs at %.1f WPM over %d attempt(s)", r.BestSeconds, r.BestWPM, r.Attempts)
+				if g.quoteBest {
+					best += " - new best!"
+				}
+				drawText(g.screen, 1, y+1, style.Foreground(tcell.ColorYellow), best)
+			}
+			y += 3
+		}
+		if g.round.Zen {
+			drawText(g.screen, 1, y, style, zenResult(g.results))
+			if g.notice != "" {
+				drawText(g.screen, 1, y+1, style.Foreground(tcell.ColorYellow), g.notice)
+			}
+			y += 3
+		}
+		if n := len(g.results.OutputLines); n > 0 {
+			drawText(g.screen, 1, y, style, fmt.Sprintf("Typed %d line(s) from the task's output:", n))
+			for i, line := range g.results.OutputLines[max(0, n-3):] {
+				drawText(g.screen, 3, y+1+i, style.Foreground(tcell.ColorYellow), "> "+line)
+			}
+			y += min(n, 3) + 2
+		}
+		if g.task != nil && g.task.IsComplete() {
+			taskStyle := style.Foreground(tcell.ColorGreen)
+			if g.task.HasError() {
+				taskStyle = style.Foreground(tcell.ColorRed)
+			}
+			drawText(g.screen, 1, y, taskStyle, g.taskOutcome())
+			y += 2
+		}
+
+		choices := g.resultChoices()
+		for i, choice := range choices {
+			choiceStyle := style
+			if i == g.selectedResult {
+				choiceStyle = choiceStyle.Background(tcell.ColorBlue)
+			}
+			drawText(g.screen, 3, y+i, choiceStyle, choice)
+		}
+		drawText(g.screen, 1, y+len(choices)+1, style, "Press ESC to exit")
 
 	case StateTaskComplete:
 		width, _ := g.screen.Size()
@@ -522,6 +993,26 @@ func (g *Game) draw() {
 		}
 	}
 
+	// Make a task blocked on a prompt impossible to miss
+	if g.task != nil && g.state != StateTaskComplete && g.task.WaitingForInput() {
+		if !g.taskWaiting {
+			g.screen.Beep()
+		}
+		g.taskWaiting = true
+		warnStyle := style.Background(tcell.ColorRed).Foreground(tcell.ColorWhite).Bold(true)
+		msg := " Task is waiting for input"
+		if prompt := g.task.Prompt(); prompt != "" {
+			msg += ": " + prompt
+		}
+		msg += " - press ESC to leave the game and answer it "
+		for x := 0; x < width; x++ {
+			g.screen.SetContent(x, 0, ' ', nil, warnStyle)
+		}
+		drawText(g.screen, 0, 0, warnStyle, msg)
+	} else {
+		g.taskWaiting = false
+	}
+
 	// Draw a clear status line at the very bottom with border
 	statusY := height - 1
 	drawText(g.screen, 1, statusY, style.Bold(true),
@@ -559,19 +1050,6 @@ func drawText(s tcell.Screen, x, y int, style tcell.Style, text string) {
 	}
 }
 
-func PrintResults(results Results) {
-	border := strings.Repeat("=", 50)
-	fmt.Println(border)
-	fmt.Println("🎯 DevTyper Results")
-	fmt.Println(border)
-	fmt.Printf("Duration: %d seconds\n", results.Duration)
-	fmt.Printf("Words per minute: %.1f\n", results.WPM)
-	fmt.Printf("Accuracy: %.1f%%\n", results.Accuracy)
-	fmt.Printf("Total words typed: %d\n", results.WordsTyped)
-	fmt.Printf("Total errors: %d\n", results.TotalErrors)
-	fmt.Println(border)
-}
-
 func max(a, b int) int {
 	if a > b {
 		return a
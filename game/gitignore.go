@@ -0,0 +1,124 @@
+package game
+
+import (
+	"bufio"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// ignoreRule is one pattern from a .gitignore file
+type ignoreRule struct {
+	base     string // Directory of the .gitignore, relative to the walk root
+	pattern  *regexp.Regexp
+	anchored bool // Matches the path below base rather than just the name
+	dirOnly  bool
+	negate   bool
+}
+
+// gitignore holds the rules of the .gitignore files seen during a walk
+type gitignore struct {
+	rules []ignoreRule
+}
+
+// load adds the rules of dir's .gitignore, dir being relative to root
+func (gi *gitignore) load(root, dir string) {
+	f, err := os.Open(filepath.Join(root, filepath.FromSlash(dir), ".gitignore"))
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), " \t\r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		rule := ignoreRule{base: dir}
+		if strings.HasPrefix(line, "!") {
+			rule.negate = true
+			line = line[1:]
+		}
+		if strings.HasSuffix(line, "/") {
+			rule.dirOnly = true
+			line = strings.TrimSuffix(line, "/")
+		}
+		rule.anchored = strings.Contains(line, "/")
+		line = strings.TrimPrefix(line, "/")
+		re, err := regexp.Compile("^" + globToRegexp(line) + "$")
+		if err != nil {
+			continue
+		}
+		rule.pattern = re
+		gi.rules = append(gi.rules, rule)
+	}
+}
+
+// ignored reports whether the path, relative to the walk root with forward
+// slashes, is ignored. The last matching rule decides, as in git.
+func (gi *gitignore) ignored(rel string, isDir bool) bool {
+	ignored := false
+	for _, r := range gi.rules {
+		if r.dirOnly && !isDir {
+			continue
+		}
+		below := rel
+		if r.base != "." {
+			if !strings.HasPrefix(rel, r.base+"/") {
+				continue
+			}
+			below = strings.TrimPrefix(rel, r.base+"/")
+		}
+		name := below
+		if !r.anchored {
+			name = path.Base(below)
+		}
+		if r.pattern.MatchString(name) {
+			ignored = !r.negate
+		}
+	}
+	return ignored
+}
+
+// globToRegexp translates gitignore glob syntax, including **, to a regexp
+func globToRegexp(glob string) string {
+	var b strings.Builder
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch {
+		case strings.HasPrefix(glob[i:], "**/"):
+			b.WriteString("(.*/)?")
+			i += 2
+		case strings.HasPrefix(glob[i:], "/**"):
+			b.WriteString("(/.*)?")
+			i += 2
+		case strings.HasPrefix(glob[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		case c == '[':
+			if end := strings.IndexByte(glob[i:], ']'); end > 0 {
+				class := glob[i+1 : i+end]
+				if strings.HasPrefix(class, "!") {
+					class = "^" + class[1:]
+				}
+				b.WriteString("[" + class + "]")
+				i += end
+				continue
+			}
+			b.WriteString(`\[`)
+		case c == '\\' && i+1 < len(glob):
+			i++
+			b.WriteString(regexp.QuoteMeta(string(glob[i])))
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return b.String()
+}
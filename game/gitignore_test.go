@@ -0,0 +1,76 @@
+package game
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGitignore(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		".gitignore":     "*.log\n!keep.log\n/build\nout/\n**/gen/*.go\ndocs/**\ndoc/*.txt\n# comment\n\n",
+		"sub/.gitignore": "*.tmp\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	gi := &gitignore{}
+	gi.load(root, ".")
+	gi.load(root, "sub")
+
+	tests := []struct {
+		rel   string
+		isDir bool
+		want  bool
+	}{
+		{"main.go", false, false},
+		{"app.log", false, true},
+		{"sub/app.log", false, true},
+		{"keep.log", false, false}, // Negated
+		{"build", true, true},      // Anchored to the root
+		{"sub/build", true, false},
+		{"out", true, true}, // Directories only
+		{"out", false, false},
+		{"sub/out", true, true},
+		{"gen/x.go", false, true}, // **/ matches no directory too
+		{"a/b/gen/x.go", false, true},
+		{"gen/x/y.go", false, false},
+		{"docs/a/b.md", false, true}, // /** matches everything below
+		{"doc/a.txt", false, true},
+		{"doc/x/a.txt", false, false}, // * doesn't cross a slash
+		{"sub/a.tmp", false, true},    // Rules apply below their .gitignore
+		{"a.tmp", false, false},
+	}
+	for _, tt := range tests {
+		if got := gi.ignored(tt.rel, tt.isDir); got != tt.want {
+			t.Errorf("ignored(%q, dir=%v) = %v, want %v", tt.rel, tt.isDir, got, tt.want)
+		}
+	}
+}
+
+func TestGlobToRegexp(t *testing.T) {
+	tests := []struct {
+		glob, want string
+	}{
+		{"*.go", `[^/]*\.go`},
+		{"a?c", `a[^/]c`},
+		{"**/gen", `(.*/)?gen`},
+		{"gen/**", `gen(/.*)?`},
+		{"a/**/b", `a(/.*)?/b`},
+		{"[!a]b", `[^a]b`},
+		{"[ab", `\[ab`},
+		{`\*x`, `\*x`},
+	}
+	for _, tt := range tests {
+		if got := globToRegexp(tt.glob); got != tt.want {
+			t.Errorf("globToRegexp(%q) = %q, want %q", tt.glob, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package game
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/parth/DevTyper/monitor"
+)
+
+// Output lines shorter than this are mostly progress noise
+const minOutputLineLen = 10
+
+// OutputGenerator serves the task's newest output lines, falling back to
+// another generator while the task has nothing new to say
+type OutputGenerator struct {
+	task       *monitor.Task
+	fallback   Generator
+	seen       int  // Output lines already looked at
+	fromOutput bool // The current text came from the output
+	wordCount  int
+}
+
+func NewOutputGenerator(task *monitor.Task, fallback Generator) *OutputGenerator {
+	return &OutputGenerator{task: task, fallback: fallback, wordCount: 25}
+}
+
+// Generate returns the newest typeable output lines, one per line, up to the
+// word count
+func (og *OutputGenerator) Generate() string {
+	lines, next := og.task.LinesSince(og.seen)
+	og.seen = next
+
+	var picked []string
+	words := 0
+	for i := len(lines) - 1; i >= 0 && words < og.wordCount; i-- {
+		line, ok := cleanOutputLine(lines[i])
+		if !ok {
+			continue
+		}
+		picked = append([]string{line}, picked...)
+		words += len(strings.Fields(line))
+	}
+
+	og.fromOutput = len(picked) > 0
+	if !og.fromOutput {
+		return og.fallback.Generate()
+	}
+	return strings.Join(picked, "\n")
+}
+
+// cleanOutputLine turns a raw output line into something typeable: escape
+// sequences go, progress redraws keep their last state and runs of spaces
+// shrink to one
+func cleanOutputLine(line string) (string, bool) {
+	line = monitor.StripANSI(line)
+	if i := strings.LastIndex(strings.TrimRight(line, "\r"), "\r"); i >= 0 {
+		line = line[i+1:]
+	}
+	line = strings.Join(strings.Fields(line), " ")
+	if len(line) < minOutputLineLen || len(line) > maxSnippetLineLen ||
+		!typeable([]string{line}) || strings.Contains(line, "[REDACTED]") {
+		return "", false
+	}
+	if strings.IndexFunc(line, unicode.IsLetter) < 0 {
+		return "", false // Just numbers and progress bars
+	}
+	return line, true
+}
+
+func (og *OutputGenerator) SetWordCount(count int) {
+	og.wordCount = count
+	og.fallback.SetWordCount(count)
+}
+
+// FromOutput reports whether the current text came from the task's output
+func (og *OutputGenerator) FromOutput() bool {
+	return og.fromOutput
+}
+
+// Attribution says where the current text comes from
+func (og *OutputGenerator) Attribution() string {
+	if og.fromOutput {
+		return "From the task's output"
+	}
+	return "Nothing new from the task, regular words meanwhile"
+}
@@ -0,0 +1,200 @@
+package game
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+
+	"github.com/parth/DevTyper/store"
+)
+
+//go:embed quotes.json
+var quotesJSON []byte
+
+type Quote struct {
+	ID     int    `json:"id"`
+	Text   string `json:"text"`
+	Source string `json:"source"`
+}
+
+// QuoteTier groups quotes by length
+type QuoteTier int
+
+const (
+	TierShort QuoteTier = iota
+	TierMedium
+	TierLong
+	TierThicc
+	TierAny
+)
+
+var quoteTierNames = map[QuoteTier]string{
+	TierShort:  "Short",
+	TierMedium: "Medium",
+	TierLong:   "Long",
+	TierThicc:  "Thicc",
+	TierAny:    "Any length",
+}
+
+func (t QuoteTier) String() string {
+	return quoteTierNames[t]
+}
+
+var quoteTiers = []QuoteTier{TierShort, TierMedium, TierLong, TierThicc, TierAny}
+
+func (q Quote) Tier() QuoteTier {
+	switch n := len(q.Text); {
+	case n <= 100:
+		return TierShort
+	case n <= 300:
+		return TierMedium
+	case n <= 600:
+		return TierLong
+	}
+	return TierThicc
+}
+
+// QuoteGenerator picks quotes of one tier from the bundled corpus
+type QuoteGenerator struct {
+	quotes  []Quote
+	tier    QuoteTier
+	current Quote
+	retry   bool // Serve the current quote again
+}
+
+func NewQuoteGenerator() (*QuoteGenerator, error) {
+	var corpus struct {
+		Quotes []Quote `json:"quotes"`
+	}
+	if err := json.Unmarshal(quotesJSON, &corpus); err != nil {
+		return nil, err
+	}
+	return &QuoteGenerator{quotes: corpus.Quotes, tier: TierAny}, nil
+}
+
+func (qg *QuoteGenerator) SetTier(tier QuoteTier) {
+	qg.tier = tier
+}
+
+// Generate picks a random quote of the tier, other than the last one
+func (qg *QuoteGenerator) Generate() string {
+	if qg.retry {
+		qg.retry = false
+		return qg.current.Text
+	}
+	var candidates []Quote
+	for _, q := range qg.quotes {
+		if (qg.tier == TierAny || q.Tier() == qg.tier) && q.ID != qg.current.ID {
+			candidates = append(candidates, q)
+		}
+	}
+	if len(candidates) > 0 {
+		qg.current = candidates[rand.Intn(len(candidates))]
+	}
+	return qg.current.Text
+}
+
+// Quotes have their own length, so the word count is ignored
+func (qg *QuoteGenerator) SetWordCount(count int) {}
+
+// Retry makes the next Generate return the current quote again
+func (qg *QuoteGenerator) Retry() {
+	qg.retry = true
+}
+
+// Count returns how many quotes the tier has
+func (qg *QuoteGenerator) Count(tier QuoteTier) int {
+	n := 0
+	for _, q := range qg.quotes {
+		if tier == TierAny || q.Tier() == tier {
+			n++
+		}
+	}
+	return n
+}
+
+func (qg *QuoteGenerator) Current() Quote {
+	return qg.current
+}
+
+func (qg *QuoteGenerator) Attribution() string {
+	return "- " + qg.current.Source
+}
+
+// QuoteRecord is the history of one quote
+type QuoteRecord struct {
+	Attempts    int     `json:"attempts"`
+	BestSeconds float64 `json:"best_seconds"`
+	BestWPM     float64 `json:"best_wpm"`
+}
+
+// QuoteHistory remembers the completed attempts at each quote, apart for
+// each ruleset
+type QuoteHistory struct {
+	path     string
+	Quotes   map[int]*QuoteRecord            `json:"quotes"`             // Under the normal rules
+	Rulesets map[string]map[int]*QuoteRecord `json:"rulesets,omitempty"` // Under the other rules, by name
+}
+
+// LoadQuoteHistory reads the history file at path. A missing file yields an
+// empty history.
+func LoadQuoteHistory(path string) (*QuoteHistory, error) {
+	h := &QuoteHistory{path: path, Quotes: map[int]*QuoteRecord{}}
+	if err := store.Load(path, h); err != nil {
+		return nil, err
+	}
+	if h.Quotes == nil {
+		h.Quotes = map[int]*QuoteRecord{}
+	}
+	return h, nil
+}
+
+// records returns the quote records kept for a ruleset
+func (h *QuoteHistory) records(rules Ruleset) map[int]*QuoteRecord {
+	if rules == RulesNormal {
+		return h.Quotes
+	}
+	if h.Rulesets == nil {
+		h.Rulesets = map[string]map[int]*QuoteRecord{}
+	}
+	if h.Rulesets[rules.String()] == nil {
+		h.Rulesets[rules.String()] = map[int]*QuoteRecord{}
+	}
+	return h.Rulesets[rules.String()]
+}
+
+// Best returns the record of a quote under a ruleset, nil if never completed
+func (h *QuoteHistory) Best(id int, rules Ruleset) *QuoteRecord {
+	return h.records(rules)[id]
+}
+
+// Empty reports whether no quote was ever completed
+func (h *QuoteHistory) Empty() bool {
+	return len(h.Quotes) == 0 && len(h.Rulesets) == 0
+}
+
+// Record adds a completed attempt under a ruleset and reports whether it is
+// the best time
+func (h *QuoteHistory) Record(id int, rules Ruleset, seconds, wpm float64) bool {
+	records := h.records(rules)
+	r := records[id]
+	if r == nil {
+		r = &QuoteRecord{}
+		records[id] = r
+	}
+	r.Attempts++
+	best := r.Attempts == 1 || seconds < r.BestSeconds
+	if best {
+		r.BestSeconds, r.BestWPM = seconds, wpm
+	}
+	return best
+}
+
+// Save writes the history back to its file
+func (h *QuoteHistory) Save() error {
+	if h.path == "" {
+		return fmt.Errorf("quote history has no file")
+	}
+	return store.Save(h.path, h)
+}
@@ -0,0 +1,263 @@
+package game
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/fs"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Limits that keep snippets typeable and the walk quick
+const (
+	maxRepoFiles       = 2000
+	maxRepoEntries     = 20000 // Files and directories visited, matching or not
+	maxRepoFileSize    = 256 * 1024
+	maxSnippetLines    = 15
+	minSnippetLines    = 3
+	maxSnippetLineLen  = 100
+	minifiedLineLength = 300 // A file with a longer line is treated as minified
+)
+
+// Source file extensions of each languages pack
+var packExtensions = map[string][]string{
+	"go":         {".go"},
+	"javascript": {".js", ".jsx", ".mjs", ".cjs", ".ts", ".tsx"},
+	"rust":       {".rs"},
+}
+
+// Lines that start a function in each languages pack
+var functionStarts = map[string]*regexp.Regexp{
+	"go":         regexp.MustCompile(`^\s*func\b`),
+	"javascript": regexp.MustCompile(`^\s*((export\s+)?(default\s+)?(async\s+)?function\b|(export\s+)?(const|let)\s+\w+\s*=\s*(async\s*)?(\([^)]*\)|\w+)\s*=>\s*\{\s*$|(static\s+)?(async\s+)?\w+\s*\([^)]*\)\s*\{\s*$)`),
+	"rust":       regexp.MustCompile(`^\s*(pub(\([^)]*\))?\s+)?(const\s+)?(async\s+)?(unsafe\s+)?fn\b`),
+}
+
+// Directories never worth walking, ignored or not
+var skippedDirs = map[string]bool{
+	".git": true, "node_modules": true, "vendor": true, "target": true, "dist": true, "build": true,
+}
+
+// Control statements that look like JavaScript method definitions
+var controlStatement = regexp.MustCompile(`^\s*(if|for|while|switch|catch|with)\b`)
+
+var generatedMarker = regexp.MustCompile(`(?i)(code generated .* do not edit|@generated|auto-generated|autogenerated)`)
+
+type repoSnippet struct {
+	file  string // Relative to the repository root
+	line  int
+	text  string
+	words int
+}
+
+// RepoGenerator serves functions taken from the source files of a directory
+type RepoGenerator struct {
+	snippets  []repoSnippet
+	current   repoSnippet
+	wordCount int
+}
+
+// NewRepoGenerator collects function-sized snippets in the given language
+// from the files below root, skipping what .gitignore excludes
+func NewRepoGenerator(root, language string) (*RepoGenerator, error) {
+	exts := packExtensions[language]
+	start := functionStarts[language]
+	if start == nil {
+		return nil, fmt.Errorf("no snippets for language %q", language)
+	}
+
+	rg := &RepoGenerator{wordCount: 25}
+	ignore := &gitignore{}
+	files, entries := 0, 0
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil // Skip what can't be read
+		}
+		// Stop early in a huge tree, such as a home directory
+		entries++
+		if entries > maxRepoEntries {
+			return filepath.SkipAll
+		}
+		rel, _ := filepath.Rel(root, path)
+		rel = filepath.ToSlash(rel)
+		if d.IsDir() {
+			if rel != "." && (skippedDirs[d.Name()] || ignore.ignored(rel, true)) {
+				return filepath.SkipDir
+			}
+			ignore.load(root, rel)
+			return nil
+		}
+		if files >= maxRepoFiles {
+			return filepath.SkipAll
+		}
+		if !hasExtension(path, exts) || strings.Contains(d.Name(), ".min.") || ignore.ignored(rel, false) {
+			return nil
+		}
+		files++
+		rg.snippets = append(rg.snippets, extractSnippets(path, rel, start)...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(rg.snippets) == 0 {
+		return nil, fmt.Errorf("no short %s functions found below %s", language, root)
+	}
+	return rg, nil
+}
+
+func hasExtension(path string, exts []string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	for _, e := range exts {
+		if ext == e {
+			return true
+		}
+	}
+	return false
+}
+
+// extractSnippets returns the short functions of a file, skipping generated
+// and minified files
+func extractSnippets(path, rel string, start *regexp.Regexp) []repoSnippet {
+	info, err := os.Stat(path)
+	if err != nil || info.Size() > maxRepoFileSize {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil || generatedMarker.Match(data[:min(len(data), 1024)]) {
+		return nil
+	}
+
+	var lines []string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), maxRepoFileSize)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), " \t\r")
+		if len(line) > minifiedLineLength {
+			return nil
+		}
+		lines = append(lines, line)
+	}
+
+	var snippets []repoSnippet
+	for i := 0; i < len(lines); i++ {
+		if !start.MatchString(lines[i]) || controlStatement.MatchString(lines[i]) {
+			continue
+		}
+		end := blockEnd(lines, i)
+		if end < 0 {
+			continue
+		}
+		body := lines[i : end+1]
+		if len(body) >= minSnippetLines && len(body) <= maxSnippetLines && typeable(body) {
+			text := strings.Join(dedent(body), "\n")
+			snippets = append(snippets, repoSnippet{
+				file:  rel,
+				line:  i + 1,
+				text:  text,
+				words: len(strings.Fields(text)),
+			})
+		}
+		i = end
+	}
+	return snippets
+}
+
+// blockEnd returns the line closing the braces opened from line start, or -1
+// if they don't close soon. Braces in strings and comments are counted too,
+// which only costs the odd snippet.
+func blockEnd(lines []string, start int) int {
+	depth, opened := 0, false
+	for i := start; i < len(lines) && i < start+maxSnippetLines*4; i++ {
+		line := lines[i]
+		if idx := strings.Index(line, "//"); idx >= 0 {
+			line = line[:idx]
+		}
+		for _, c := range line {
+			switch c {
+			case '{':
+				depth++
+				opened = true
+			case '}':
+				depth--
+			}
+		}
+		if opened && depth <= 0 {
+			return i
+		}
+	}
+	return -1
+}
+
+// typeable rejects long lines and characters missing from most keyboards
+func typeable(lines []string) bool {
+	for _, line := range lines {
+		if len(line) > maxSnippetLineLen {
+			return false
+		}
+		for _, c := range line {
+			if c > '~' || (c < ' ' && c != '\t') {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// dedent removes the indentation all lines share, keeping the rest
+func dedent(lines []string) []string {
+	prefix := ""
+	for i, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+		if i == 0 {
+			prefix = indent
+			continue
+		}
+		for !strings.HasPrefix(indent, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+	out := make([]string, len(lines))
+	for i, line := range lines {
+		out[i] = strings.TrimPrefix(line, prefix)
+	}
+	return out
+}
+
+// Generate picks a random function close to the word count
+func (rg *RepoGenerator) Generate() string {
+	offset := rand.Intn(len(rg.snippets))
+	rg.current = rg.snippets[offset]
+	for i := range rg.snippets {
+		s := rg.snippets[(offset+i)%len(rg.snippets)]
+		if s.words >= rg.wordCount/2 && s.words <= rg.wordCount*2 {
+			rg.current = s
+			break
+		}
+	}
+	return rg.current.text
+}
+
+func (rg *RepoGenerator) SetWordCount(count int) {
+	rg.wordCount = count
+}
+
+// Attribution returns where the current snippet comes from, e.g. "main.go:12"
+func (rg *RepoGenerator) Attribution() string {
+	return fmt.Sprintf("%s:%d", rg.current.file, rg.current.line)
+}
+
+// repoRoot returns the directory repository snippets come from
+func repoRoot() string {
+	if cwd, err := os.Getwd(); err == nil {
+		return cwd
+	}
+	return "."
+}
@@ -0,0 +1,77 @@
+package game
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExtractSnippets(t *testing.T) {
+	long := "func long() {\n" + strings.Repeat("\tx++\n", 20) + "}\n"
+	tests := []struct {
+		name, language, content string
+		want                    []string
+	}{
+		{"short.go", "go", "package a\n\nfunc add(a, b int) int {\n\tsum := a + b\n\treturn sum\n}\n" + long,
+			[]string{"func add(a, b int) int {\n\tsum := a + b\n\treturn sum\n}"}},
+		{"generated.go", "go", "// Code generated by stringer. DO NOT EDIT.\n\nfunc add(a, b int) int {\n\tsum := a + b\n\treturn sum\n}\n",
+			nil},
+		// Methods share the class body's tab, which is removed
+		{"class.js", "javascript", "class Greeter {\n\tgreet(name) {\n\t\tconst msg = 'hi ' + name\n\t\treturn msg\n\t}\n}\n",
+			[]string{"greet(name) {\n\tconst msg = 'hi ' + name\n\treturn msg\n}"}},
+		{"control.js", "javascript", "function f() {\n\tif (x) {\n\t\ty()\n\t}\n}\n",
+			[]string{"function f() {\n\tif (x) {\n\t\ty()\n\t}\n}"}},
+		{"minified.js", "javascript", "function f() {\n\treturn 1\n}\n" + strings.Repeat("a", minifiedLineLength+1) + "\n",
+			nil},
+	}
+	dir := t.TempDir()
+	for _, tt := range tests {
+		path := filepath.Join(dir, tt.name)
+		if err := os.WriteFile(path, []byte(tt.content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		snippets := extractSnippets(path, tt.name, functionStarts[tt.language])
+		var got []string
+		for _, s := range snippets {
+			got = append(got, s.text)
+		}
+		if strings.Join(got, "\n--\n") != strings.Join(tt.want, "\n--\n") {
+			t.Errorf("%s: got snippets %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestBlockEnd(t *testing.T) {
+	tests := []struct {
+		lines []string
+		want  int
+	}{
+		{[]string{"func f() {", "}"}, 1},
+		{[]string{"func f() { // {", "\tg()", "}"}, 2},
+		{[]string{"func f()", "{", "\tif x { y() }", "}", "func g() {}"}, 3},
+		{[]string{"func f() {", "\tg()"}, -1},
+	}
+	for _, tt := range tests {
+		if got := blockEnd(tt.lines, 0); got != tt.want {
+			t.Errorf("blockEnd(%q) = %d, want %d", tt.lines, got, tt.want)
+		}
+	}
+}
+
+func TestDedent(t *testing.T) {
+	tests := []struct {
+		lines, want []string
+	}{
+		{[]string{"\tf() {", "\t\tg()", "", "\t}"}, []string{"f() {", "\tg()", "", "}"}},
+		{[]string{"    f() {", "      g()", "    }"}, []string{"f() {", "  g()", "}"}},
+		{[]string{"\t\tf() {", "\tg()", "\t\t}"}, []string{"\tf() {", "g()", "\t}"}},
+		{[]string{"f() {", "}"}, []string{"f() {", "}"}},
+	}
+	for _, tt := range tests {
+		got := dedent(tt.lines)
+		if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+			t.Errorf("dedent(%q) = %q, want %q", tt.lines, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package game
+
+import (
+	"fmt"
+	"time"
+	"unicode"
+)
+
+// Round is the length of a typing round: a number of words, a time limit,
+// the running task or a quote
+type Round struct {
+	Words     int
+	Duration  time.Duration
+	UntilTask bool // Ends when the task finishes
+	Quote     bool // Ends when the quote is typed
+	Zen       bool // No target text, ends on ESC or with the task
+}
+
+func (r Round) String() string {
+	if r.Zen {
+		return "zen"
+	}
+	if r.UntilTask {
+		return "Until the task finishes"
+	}
+	if r.Quote {
+		return "quote"
+	}
+	if r.Duration > 0 {
+		return fmt.Sprintf("%d seconds", int(r.Duration.Seconds()))
+	}
+	return fmt.Sprintf("%d words", r.Words)
+}
+
+func (r Round) timed() bool {
+	return r.Duration > 0
+}
+
+// endsWithText reports whether the round is over once its text is typed
+func (r Round) endsWithText() bool {
+	return r.Words > 0 || r.Quote
+}
+
+var defaultRounds = []Round{
+	{UntilTask: true},
+	{Words: 10},
+	{Words: 25},
+	{Words: 50},
+	{Words: 100},
+	{Duration: 15 * time.Second},
+	{Duration: 30 * time.Second},
+	{Duration: 60 * time.Second},
+	{Duration: 120 * time.Second},
+}
+
+// Choices on the results screen
+const (
+	choicePlayAgain  = "Play again"
+	choiceRetryQuote = "Retry quote"
+	choiceNextQuote  = "Next quote"
+	choiceSaveText   = "Save text to a file"
+	choiceChangeMode = "Change mode"
+	choiceExit       = "Exit"
+)
+
+// resultChoices lists what can be done after a round. Another round until the
+// task finishes makes no sense once it has.
+func (g *Game) resultChoices() []string {
+	if g.round.Zen {
+		var choices []string
+		if len(g.userInput) > 0 && !g.zenSaved {
+			choices = append(choices, choiceSaveText)
+		}
+		if g.task == nil || !g.task.IsComplete() {
+			choices = append(choices, choicePlayAgain)
+		}
+		return append(choices, choiceChangeMode, choiceExit)
+	}
+	if g.round.UntilTask && g.task != nil && g.task.IsComplete() {
+		return []string{choiceChangeMode, choiceExit}
+	}
+	if g.round.Quote {
+		return []string{choiceRetryQuote, choiceNextQuote, choiceChangeMode, choiceExit}
+	}
+	return []string{choicePlayAgain, choiceChangeMode, choiceExit}
+}
+
+// startRound starts a round with fresh statistics
+func (g *Game) startRound(round Round) {
+	g.round = round
+	words := g.round.Words
+	if words == 0 {
+		words = 25 // Texts keep coming until the round ends
+	}
+	g.generator.SetWordCount(words)
+	g.stats = NewStats()
+	g.lastStroke = time.Time{}
+	g.outputLines = nil
+	if round.Zen {
+		g.keyGaps, g.zenSaved, g.notice = nil, false, ""
+		g.currentSentence = ""
+	} else {
+		g.currentSentence = g.generator.Generate()
+	}
+	g.updateCurrentChars()
+	g.state = StatePlaying
+}
+
+// endRound stops the clock and shows the results
+func (g *Game) endRound() {
+	g.stats.endTime = time.Now()
+	if g.round.timed() {
+		g.stats.endTime = g.stats.startTime.Add(g.round.Duration)
+	}
+	if !g.round.endsWithText() && !g.round.Zen {
+		g.stats.wordsTyped += g.partialWords()
+	}
+	if g.round.Quote && string(g.userInput) == g.currentSentence {
+		g.quoteBest = g.QuoteHistory.Record(g.quotes.Current().ID, g.rules,
+			g.stats.elapsed().Seconds(), g.stats.calculateWPM())
+	}
+	g.saveResults()
+	g.selectedResult = 0
+	g.state = StateResults
+}
+
+// roundOver reports whether a timed round ran out of time, or the task of a
+// round lasting until it finishes is done
+func (g *Game) roundOver() bool {
+	if g.round.timed() {
+		return g.timeLeft() == 0
+	}
+	return g.round.UntilTask && g.task != nil && g.task.IsComplete()
+}
+
+// timeLeft returns the time remaining in a timed round
+func (g *Game) timeLeft() time.Duration {
+	left := g.round.Duration - time.Since(g.stats.startTime)
+	if left < 0 {
+		return 0
+	}
+	return left
+}
+
+// partialWords counts the words of the unfinished text typed correctly,
+// including the space after them
+func (g *Game) partialWords() int {
+	count, inWord, correct := 0, false, true
+	for i := range g.userInput {
+		cs := g.currentChars[i]
+		if unicode.IsSpace(cs.char) {
+			if inWord && correct {
+				count++
+			}
+			inWord, correct = false, true
+			continue
+		}
+		inWord = true
+		correct = correct && cs.correct
+	}
+	if inWord && correct && len(g.userInput) == len(g.currentChars) {
+		count++ // The last word of a text typed to the end
+	}
+	return count
+}
+
+// taskOutcome describes how the task ended, for the results screen
+func (g *Game) taskOutcome() string {
+	took := formatClock(g.task.Duration())
+	if g.task.HasError() {
+		return fmt.Sprintf("%s failed after %s: %s", g.taskDescription, took, g.task.GetError())
+	}
+	return fmt.Sprintf("%s finished in %s (exit code %d)", g.taskDescription, took, g.task.ExitCode())
+}
+
+// formatClock formats d as minutes and seconds, e.g. "1:05"
+func formatClock(d time.Duration) string {
+	d = d.Round(time.Second)
+	return fmt.Sprintf("%d:%02d", int(d.Minutes()), int(d.Seconds())%60)
+}
@@ -2,6 +2,7 @@ package game
 
 import (
 	"math/rand"
+	"strconv"
 	"strings"
 )
 
@@ -19,9 +20,65 @@ var genericWords = []string{
 	"while", "those", "always", "world", "both", "life", "where", "next", "being", "keep",
 }
 
+// Generator produces the text for a round of typing practice
+type Generator interface {
+	Generate() string
+	SetWordCount(count int)
+}
+
+// attributed generators say where their text comes from
+type attributed interface {
+	Attribution() string
+}
+
+// Modifiers make generated words look more like real writing
+type Modifiers struct {
+	Capitals    bool // Capitalize the start of sentences
+	Punctuation bool // Commas, full stops and the like after words
+	Quotes      bool // Wrap the odd word in quotes or parentheses
+	Numbers     bool // Replace the odd word with a number
+}
+
+// Names lists the modifiers that are on, for recording with results
+func (m Modifiers) Names() []string {
+	var names []string
+	for _, mod := range []struct {
+		on   bool
+		name string
+	}{
+		{m.Capitals, "capitals"},
+		{m.Punctuation, "punctuation"},
+		{m.Quotes, "quotes"},
+		{m.Numbers, "numbers"},
+	} {
+		if mod.on {
+			names = append(names, mod.name)
+		}
+	}
+	return names
+}
+
+// Punctuation after a word and how often it appears, roughly as in English
+// prose: a sentence runs about a dozen words with a comma or two in it
+var punctuationMarks = []struct {
+	mark         string
+	oneIn        int
+	endsSentence bool
+}{
+	{".", 12, true},
+	{",", 10, false},
+	{"?", 60, true},
+	{"!", 80, true},
+	{";", 80, false},
+	{":", 100, false},
+}
+
+var wrappers = [][2]string{{`"`, `"`}, {"'", "'"}, {"(", ")"}}
+
 type SentenceGenerator struct {
 	currentSentence string
 	wordCount       int
+	Modifiers       Modifiers
 }
 
 func NewSentenceGenerator() *SentenceGenerator {
@@ -35,6 +92,7 @@ func (sg *SentenceGenerator) Generate() string {
 	for i := 0; i < sg.wordCount; i++ {
 		words[i] = genericWords[rand.Intn(len(genericWords))]
 	}
+	sg.modify(words)
 	sg.currentSentence = strings.Join(words, " ")
 	return sg.currentSentence
 }
@@ -42,3 +100,33 @@ func (sg *SentenceGenerator) Generate() string {
 func (sg *SentenceGenerator) SetWordCount(count int) {
 	sg.wordCount = count
 }
+
+// modify applies the modifiers to the words in place
+func (sg *SentenceGenerator) modify(words []string) {
+	m := sg.Modifiers
+	sentenceStart := true
+	for i, word := range words {
+		if m.Numbers && rand.Intn(8) == 0 {
+			word = strconv.Itoa(rand.Intn(1000))
+		} else if m.Capitals && sentenceStart {
+			word = strings.ToUpper(word[:1]) + word[1:]
+		}
+		if m.Quotes && rand.Intn(15) == 0 {
+			w := wrappers[rand.Intn(len(wrappers))]
+			word = w[0] + word + w[1]
+		}
+
+		sentenceStart = false
+		if m.Punctuation {
+			last := i == len(words)-1
+			for _, p := range punctuationMarks {
+				if (last && p.endsSentence) || (!last && rand.Intn(p.oneIn) == 0) {
+					word += p.mark
+					sentenceStart = p.endsSentence
+					break
+				}
+			}
+		}
+		words[i] = word
+	}
+}
@@ -0,0 +1,202 @@
+package game
+
+import (
+	"bufio"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/parth/DevTyper/monitor"
+)
+
+// Only the most recent commands are kept
+const maxShellCommands = 5000
+
+// Commands mentioning these are skipped even when nothing was redacted
+var secretWords = regexp.MustCompile(`(?i)(passw|token|secret|api[-_]?key|private[-_]?key|credential)`)
+
+var (
+	bashTimestamp = regexp.MustCompile(`^#\d+$`)
+	zshExtended   = regexp.MustCompile(`^: \d+:\d+;`)
+)
+
+type shellCommand struct {
+	text    string
+	program string // What the command ends up running, e.g. "docker"
+	words   int
+}
+
+// ShellGenerator serves commands from the player's shell history, one per line
+type ShellGenerator struct {
+	commands   []shellCommand
+	tools      []string // Tool family of the running task
+	Restricted bool     // Only serve commands of the tool family
+	wordCount  int
+}
+
+// LoadShellHistory reads the bash, zsh and fish histories below home.
+// Duplicates, multi-line commands and anything that looks like it holds a
+// secret are left out.
+func LoadShellHistory(home string, redactor *monitor.Redactor) (*ShellGenerator, error) {
+	fishDir := os.Getenv("XDG_DATA_HOME")
+	if fishDir == "" {
+		fishDir = filepath.Join(home, ".local", "share")
+	}
+
+	var lines []string
+	lines = append(lines, readHistory(filepath.Join(home, ".bash_history"), parseBashLine)...)
+	lines = append(lines, readHistory(filepath.Join(home, ".zsh_history"), parseZshLine)...)
+	lines = append(lines, readHistory(filepath.Join(fishDir, "fish", "fish_history"), parseFishLine)...)
+
+	// Walk backwards so the most recent copy of a command is the one kept
+	sg := &ShellGenerator{wordCount: 25}
+	seen := map[string]bool{}
+	for i := len(lines) - 1; i >= 0 && len(sg.commands) < maxShellCommands; i-- {
+		cmd := strings.TrimSpace(lines[i])
+		if seen[cmd] || !practicable(cmd, redactor) {
+			continue
+		}
+		seen[cmd] = true
+		sg.commands = append(sg.commands, shellCommand{
+			text:    cmd,
+			program: commandProgram(cmd),
+			words:   len(strings.Fields(cmd)),
+		})
+	}
+	if len(sg.commands) == 0 {
+		return nil, fmt.Errorf("no commands found in the shell history")
+	}
+	return sg, nil
+}
+
+// readHistory returns the commands of a history file, parse turning each line
+// into a command. Lines continued with a backslash are dropped with the
+// command they belong to.
+func readHistory(path string, parse func(string) (string, bool)) []string {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	var commands []string
+	continued := false
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		wasContinued := continued
+		continued = strings.HasSuffix(line, "\\")
+		if wasContinued || continued {
+			continue
+		}
+		if cmd, ok := parse(line); ok {
+			commands = append(commands, cmd)
+		}
+	}
+	return commands
+}
+
+func parseBashLine(line string) (string, bool) {
+	return line, !bashTimestamp.MatchString(line)
+}
+
+// parseZshLine also reads the extended format, ": <start>:<elapsed>;<command>"
+func parseZshLine(line string) (string, bool) {
+	return zshExtended.ReplaceAllString(line, ""), true
+}
+
+// parseFishLine picks the commands out of fish's YAML-like history
+func parseFishLine(line string) (string, bool) {
+	cmd, ok := strings.CutPrefix(line, "- cmd: ")
+	if !ok || strings.Contains(cmd, `\n`) {
+		return "", false
+	}
+	return strings.ReplaceAll(cmd, `\\`, `\`), true
+}
+
+// practicable keeps commands worth typing: more than a bare program name,
+// short, typeable and free of secrets
+func practicable(cmd string, redactor *monitor.Redactor) bool {
+	if len(strings.Fields(cmd)) < 2 || !typeable([]string{cmd}) || strings.Contains(cmd, "\t") {
+		return false
+	}
+	return !secretWords.MatchString(cmd) && redactor.Redact(cmd) == cmd
+}
+
+// commandProgram returns the program a command runs, looking past wrappers
+// such as sudo
+func commandProgram(cmd string) string {
+	argv, err := monitor.SplitCommandLine(cmd)
+	if err != nil {
+		argv = strings.Fields(cmd)
+	}
+	cl := monitor.ParseCommandLine(argv)
+	if len(cl.Effective) == 0 {
+		return ""
+	}
+	return cl.Effective[0]
+}
+
+// SetTools sets the tool family of the running task, e.g. "docker" and
+// "docker-compose", and restricts the commands to it if any match
+func (sg *ShellGenerator) SetTools(tools []string) {
+	sg.tools = tools
+	sg.Restricted = sg.toolCommands() > 0
+}
+
+// toolCommands counts the commands of the tool family
+func (sg *ShellGenerator) toolCommands() int {
+	n := 0
+	for _, c := range sg.commands {
+		if sg.inFamily(c) {
+			n++
+		}
+	}
+	return n
+}
+
+func (sg *ShellGenerator) inFamily(c shellCommand) bool {
+	for _, t := range sg.tools {
+		if c.program == t {
+			return true
+		}
+	}
+	return false
+}
+
+// Generate picks random commands, one per line, until they hold the word count
+func (sg *ShellGenerator) Generate() string {
+	var pool []shellCommand
+	for _, c := range sg.commands {
+		if !sg.Restricted || sg.inFamily(c) {
+			pool = append(pool, c)
+		}
+	}
+
+	var lines []string
+	words := 0
+	for _, i := range rand.Perm(len(pool)) {
+		lines = append(lines, pool[i].text)
+		words += pool[i].words
+		if words >= sg.wordCount {
+			break
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (sg *ShellGenerator) SetWordCount(count int) {
+	sg.wordCount = count
+}
+
+// Attribution says which commands are served
+func (sg *ShellGenerator) Attribution() string {
+	if sg.Restricted {
+		return strings.Join(sg.tools, ", ") + " commands from your shell history"
+	}
+	return "Commands from your shell history"
+}
@@ -0,0 +1,66 @@
+package game
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseHistoryLines(t *testing.T) {
+	tests := []struct {
+		parse func(string) (string, bool)
+		line  string
+		want  string
+		ok    bool
+	}{
+		{parseBashLine, "git status", "git status", true},
+		{parseBashLine, "#1700000000", "", false},
+		{parseZshLine, ": 1700000000:0;docker ps -a", "docker ps -a", true},
+		{parseZshLine, "ls -la", "ls -la", true},
+		{parseFishLine, "- cmd: kubectl get pods", "kubectl get pods", true},
+		{parseFishLine, `- cmd: echo a\\b`, `echo a\b`, true},
+		{parseFishLine, `- cmd: for x in a\nend`, "", false},
+		{parseFishLine, "  when: 1700000000", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := tt.parse(tt.line)
+		if ok != tt.ok || (ok && got != tt.want) {
+			t.Errorf("parsing %q = %q, %v, want %q, %v", tt.line, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestReadHistory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history")
+	content := ": 1:0;git pull\n: 2:0;docker build \\\n  -t app .\n: 3:0;make test\n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	// The continued docker command is dropped with its continuation
+	got := readHistory(path, parseZshLine)
+	if want := []string{"git pull", "make test"}; strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("readHistory = %q, want %q", got, want)
+	}
+	if got := readHistory(filepath.Join(t.TempDir(), "missing"), parseBashLine); got != nil {
+		t.Errorf("readHistory of a missing file = %q, want nothing", got)
+	}
+}
+
+func TestPracticable(t *testing.T) {
+	tests := []struct {
+		cmd  string
+		want bool
+	}{
+		{"docker compose up -d", true},
+		{"ls", false},
+		{"mysql -u root --password=hunter2", false},
+		{"export GITHUB_TOKEN=abc", false},
+		{"echo héllo wörld", false},
+	}
+	for _, tt := range tests {
+		if got := practicable(tt.cmd, nil); got != tt.want {
+			t.Errorf("practicable(%q) = %v, want %v", tt.cmd, got, tt.want)
+		}
+	}
+}
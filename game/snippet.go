@@ -0,0 +1,253 @@
+package game
+
+import (
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// IndentOptions controls how indentation in multi-line snippets is typed
+type IndentOptions struct {
+	Typed   bool // Indentation must be typed instead of being skipped
+	UseTabs bool // Indent with tabs instead of spaces
+	Width   int  // Spaces per indent level, and the display width of a tab
+}
+
+func DefaultIndentOptions() IndentOptions {
+	return IndentOptions{Width: 4}
+}
+
+// unit returns the text of one indent level
+func (o IndentOptions) unit() string {
+	if o.UseTabs {
+		return "\t"
+	}
+	return strings.Repeat(" ", max(1, o.Width))
+}
+
+// cell is where a character of the target text is drawn, relative to the
+// top left of the text area
+type cell struct {
+	x, y  int
+	width int
+}
+
+// layoutText places every rune of text, wrapping long lines at spaces while
+// keeping line breaks and indentation. A newline sits just after the last
+// character of its line.
+func layoutText(text []rune, width, tabWidth int) ([]cell, int) {
+	cells := make([]cell, len(text))
+	x, y := 0, 0
+	lineStart := 0 // Index of the first rune of the current screen row
+
+	for i := 0; i < len(text); i++ {
+		r := text[i]
+		w := 1
+		if r == '\t' {
+			w = max(1, tabWidth)
+		}
+
+		// Wrap before a rune that would overflow, at the last space if any
+		if r != '\n' && x+w > width && x > 0 {
+			brk := -1
+			for j := i - 1; j > lineStart; j-- {
+				if text[j] == ' ' {
+					brk = j
+					break
+				}
+			}
+			if brk >= 0 {
+				i = brk + 1
+				r = text[i]
+				w = 1
+				if r == '\t' {
+					w = max(1, tabWidth)
+				}
+			}
+			x, y = 0, y+1
+			lineStart = i
+		}
+
+		cells[i] = cell{x: x, y: y, width: w}
+		if r == '\n' {
+			x, y = 0, y+1
+			lineStart = i + 1
+			continue
+		}
+		x += w
+	}
+	return cells, y + 1
+}
+
+// Markers that make mistyped whitespace visible
+func whitespaceMarker(r rune) rune {
+	switch r {
+	case ' ':
+		return '·'
+	case '\t':
+		return '→'
+	case '\n':
+		return '↵'
+	}
+	return r
+}
+
+// drawTarget draws the text to type at (left, top) and places the cursor.
+// Text taller than height scrolls to keep the cursor's row in view.
+func (g *Game) drawTarget(left, top, width, height int, style tcell.Style) {
+	text := make([]rune, len(g.currentChars))
+	for i, cs := range g.currentChars {
+		text[i] = cs.char
+	}
+	cells, rows := layoutText(text, width, g.Indent.Width)
+	currentPos := len(g.userInput)
+
+	cursorRow := rows - 1
+	if currentPos < len(cells) {
+		cursorRow = cells[currentPos].y
+	}
+	scroll := 0
+	if rows > height {
+		scroll = min(max(0, cursorRow-height/2), rows-height)
+	}
+	top -= scroll
+
+	for i, cs := range g.currentChars {
+		c := cells[i]
+		if c.y < scroll || c.y >= scroll+height {
+			continue
+		}
+		charStyle := style
+		ch := cs.char
+		if ch == '\n' || ch == '\t' {
+			ch = ' '
+		}
+
+		if i == currentPos {
+			charStyle = charStyle.Background(tcell.ColorWhite).Foreground(tcell.ColorBlack)
+			if cs.missed {
+				charStyle = charStyle.Background(tcell.ColorRed)
+			}
+			if cs.char == '\n' {
+				ch = whitespaceMarker('\n')
+			}
+		} else if cs.typed {
+			if cs.correct {
+				charStyle = charStyle.Foreground(tcell.ColorGreen)
+			} else {
+				charStyle = charStyle.Foreground(tcell.ColorRed)
+				ch = whitespaceMarker(cs.char)
+			}
+		}
+
+		g.screen.SetContent(left+c.x, top+c.y, ch, nil, charStyle)
+		for k := 1; k < c.width; k++ {
+			g.screen.SetContent(left+c.x+k, top+c.y, ' ', nil, charStyle)
+		}
+	}
+
+	// Cursor sits on the next character, or just after the last one
+	switch {
+	case currentPos < len(cells):
+		g.cursorX, g.cursorY = left+cells[currentPos].x, top+cells[currentPos].y
+	case len(cells) > 0:
+		last := cells[len(cells)-1]
+		g.cursorX, g.cursorY = left+last.x+last.width, top+last.y
+		if text[len(text)-1] == '\n' {
+			g.cursorX, g.cursorY = left, top+last.y+1
+		}
+	default:
+		g.cursorX, g.cursorY = left, top
+	}
+}
+
+// typeRune handles one typed character against the target
+func (g *Game) typeRune(r rune) {
+	if len(g.userInput) >= len(g.currentChars) {
+		return
+	}
+	pos := len(g.userInput)
+	g.stats.totalStrokes++
+
+	cs := &g.currentChars[pos]
+	correct := r == cs.char
+	if !correct {
+		g.stats.errorStrokes++
+	}
+	g.stats.recordChar(cs.char, correct)
+
+	var prev rune
+	if pos > 0 {
+		prev = g.currentChars[pos-1].char
+	}
+	var latency time.Duration
+	if !g.lastStroke.IsZero() {
+		latency = time.Since(g.lastStroke)
+	}
+	g.lastStroke = time.Now()
+	g.KeyStats.Record(prev, cs.char, correct, latency)
+
+	if g.blocked(pos, correct) {
+		cs.missed = true
+		return
+	}
+	g.userInput = append(g.userInput, r)
+	cs.typed = true
+	cs.correct = correct
+
+	if cs.char == '\n' && cs.correct && !g.Indent.Typed {
+		g.skipIndent()
+	}
+}
+
+// typeTab types a tab, or a run of spaces up to the next indent stop when the
+// snippet is indented with spaces
+func (g *Game) typeTab() {
+	pos := len(g.userInput)
+	if pos >= len(g.currentChars) || g.currentChars[pos].char != ' ' {
+		g.typeRune('\t')
+		return
+	}
+	for n := 0; n < max(1, g.Indent.Width); n++ {
+		pos = len(g.userInput)
+		if pos >= len(g.currentChars) || g.currentChars[pos].char != ' ' {
+			break
+		}
+		g.typeRune(' ')
+	}
+}
+
+// skipIndent fills in the indentation at the start of a line for the player
+func (g *Game) skipIndent() {
+	for pos := len(g.userInput); pos < len(g.currentChars); pos++ {
+		cs := &g.currentChars[pos]
+		if cs.char != ' ' && cs.char != '\t' {
+			return
+		}
+		g.userInput = append(g.userInput, cs.char)
+		cs.typed, cs.correct, cs.auto = true, true, true
+	}
+}
+
+// backspace removes the last typed character. Indentation that was skipped
+// automatically goes together with the newline before it.
+func (g *Game) backspace() {
+	if g.rules == RulesConfidence {
+		return
+	}
+	for len(g.userInput) > 0 {
+		pos := len(g.userInput) - 1
+		auto := g.currentChars[pos].auto
+		g.currentChars[pos] = CharacterState{char: g.currentChars[pos].char}
+		g.userInput = g.userInput[:pos]
+		if !auto {
+			return
+		}
+	}
+}
+
+// isMultiline reports whether Enter types a newline rather than submitting
+func (g *Game) isMultiline() bool {
+	return strings.ContainsRune(g.currentSentence, '\n')
+}
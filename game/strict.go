@@ -0,0 +1,51 @@
+package game
+
+import "unicode"
+
+// Ruleset decides what happens to mistakes while typing. Results of
+// different rulesets aren't comparable.
+type Ruleset int
+
+const (
+	RulesNormal       Ruleset = iota
+	RulesStopOnLetter         // A wrong key is not accepted
+	RulesStopOnWord           // A word with mistakes can't be left
+	RulesConfidence           // No backspace
+)
+
+var rulesetNames = map[Ruleset]string{
+	RulesNormal:       "normal",
+	RulesStopOnLetter: "stop on letter",
+	RulesStopOnWord:   "stop on word",
+	RulesConfidence:   "confidence",
+}
+
+func (r Ruleset) String() string {
+	return rulesetNames[r]
+}
+
+// next cycles through the rulesets
+func (r Ruleset) next() Ruleset {
+	return (r + 1) % Ruleset(len(rulesetNames))
+}
+
+// blocked reports whether the ruleset refuses a keystroke at pos
+func (g *Game) blocked(pos int, correct bool) bool {
+	switch g.rules {
+	case RulesStopOnLetter:
+		return !correct
+	case RulesStopOnWord:
+		return unicode.IsSpace(g.currentChars[pos].char) && g.wordHasError(pos)
+	}
+	return false
+}
+
+// wordHasError reports whether the word before pos was mistyped
+func (g *Game) wordHasError(pos int) bool {
+	for i := pos - 1; i >= 0 && !unicode.IsSpace(g.currentChars[i].char); i-- {
+		if !g.currentChars[i].correct {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,194 @@
+package game
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// TextGenerator serves passages of the player's own text in order, so a long
+// document can be worked through over several sessions
+type TextGenerator struct {
+	Source    string // Identifies the text for remembering the position
+	words     []string
+	pos       int // First word of the current passage
+	end       int // Word after the current passage
+	wordCount int
+}
+
+// LoadText reads practice text from a file, from every .txt and .md file in a
+// directory, or from stdin when path is "-". Markdown is reduced to its prose.
+func LoadText(path string) (*TextGenerator, error) {
+	var text, source string
+	switch info, err := os.Stat(path); {
+	case path == "-":
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return nil, err
+		}
+		text = stripMarkdown(string(data))
+		source = fmt.Sprintf("stdin:%x", sha256.Sum256(data))[:22]
+	case err != nil:
+		return nil, err
+	case info.IsDir():
+		if text, err = loadTextDir(path); err != nil {
+			return nil, err
+		}
+		source, _ = filepath.Abs(path)
+	default:
+		if text, err = loadTextFile(path); err != nil {
+			return nil, err
+		}
+		source, _ = filepath.Abs(path)
+	}
+
+	words := strings.Fields(normalizeText(text))
+	if len(words) == 0 {
+		return nil, fmt.Errorf("no text to practice in %s", path)
+	}
+	return &TextGenerator{Source: source, words: words, wordCount: 25}, nil
+}
+
+func loadTextFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	if isMarkdown(path) {
+		return stripMarkdown(string(data)), nil
+	}
+	return string(data), nil
+}
+
+// loadTextDir joins the text files below dir in path order
+func loadTextDir(dir string) (string, error) {
+	var texts []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != dir && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if ext := strings.ToLower(filepath.Ext(path)); ext != ".txt" && !isMarkdown(path) {
+			return nil
+		}
+		text, err := loadTextFile(path)
+		if err != nil {
+			return err
+		}
+		texts = append(texts, text)
+		return nil
+	})
+	return strings.Join(texts, "\n\n"), err
+}
+
+func isMarkdown(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".md", ".markdown":
+		return true
+	}
+	return false
+}
+
+var (
+	mdFence    = regexp.MustCompile("(?ms)^\\s*(```|~~~).*?^\\s*(```|~~~)[^\\n]*$")
+	mdHTML     = regexp.MustCompile(`<[^>\n]+>`)
+	mdImage    = regexp.MustCompile(`!\[([^\]]*)\]\([^)]*\)`)
+	mdLink     = regexp.MustCompile(`\[([^\]]*)\]\([^)]*\)`)
+	mdRefLink  = regexp.MustCompile(`(?m)^\s*\[[^\]]+\]:\s*\S+.*$`)
+	mdLineMark = regexp.MustCompile(`(?m)^\s*(#{1,6}\s+|>\s?|[-*+]\s+\[[ xX]\]\s+|[-*+]\s+|\d+[.)]\s+)`)
+	mdRule     = regexp.MustCompile(`(?m)^\s*([-*_]\s*){3,}$|^\s*\|?[\s:|-]+\|[\s:|-]*$`)
+	// Emphasis and code spans; a lone "*" as in "2 * 3" is kept
+	mdStrong = regexp.MustCompile(`\*\*(\S(?:[^*\n]*\S)?)\*\*|__(\S(?:[^_\n]*\S)?)__`)
+	mdEm     = regexp.MustCompile(`\*(\S(?:[^*\n]*\S)?)\*`)
+	mdCode   = regexp.MustCompile("`([^`\n]*)`")
+)
+
+// stripMarkdown keeps the prose of a Markdown document, dropping code blocks,
+// link targets and formatting
+func stripMarkdown(text string) string {
+	text = mdFence.ReplaceAllString(text, "")
+	text = mdHTML.ReplaceAllString(text, "")
+	text = mdImage.ReplaceAllString(text, "$1")
+	text = mdLink.ReplaceAllString(text, "$1")
+	text = mdRefLink.ReplaceAllString(text, "")
+	text = mdRule.ReplaceAllString(text, "")
+	text = mdLineMark.ReplaceAllString(text, "")
+	text = mdStrong.ReplaceAllString(text, "$1$2")
+	text = mdEm.ReplaceAllString(text, "$1")
+	text = mdCode.ReplaceAllString(text, "$1")
+	return strings.ReplaceAll(text, "|", " ")
+}
+
+// Typographic characters most keyboards can't type directly
+var plainPunctuation = strings.NewReplacer(
+	"‘", "'", "’", "'", "“", `"`, "”", `"`,
+	"–", "-", "—", "-", "…", "...", "\u00a0", " ",
+)
+
+func normalizeText(text string) string {
+	return plainPunctuation.Replace(text)
+}
+
+// Generate returns the passage after the current one, starting over at the
+// end of the text. Passages run on to the end of a sentence when one is near.
+func (tg *TextGenerator) Generate() string {
+	tg.pos = tg.end
+	if tg.pos >= len(tg.words) {
+		tg.pos = 0
+	}
+
+	end := min(tg.pos+tg.wordCount, len(tg.words))
+	if !endsSentence(tg.words[end-1]) {
+		for i := end; i < min(end+tg.wordCount/2, len(tg.words)); i++ {
+			if endsSentence(tg.words[i]) {
+				end = i + 1
+				break
+			}
+		}
+	}
+
+	tg.end = end
+	return strings.Join(tg.words[tg.pos:tg.end], " ")
+}
+
+func endsSentence(word string) bool {
+	word = strings.TrimRight(word, `"')`)
+	return strings.HasSuffix(word, ".") || strings.HasSuffix(word, "?") || strings.HasSuffix(word, "!")
+}
+
+func (tg *TextGenerator) SetWordCount(count int) {
+	tg.wordCount = count
+}
+
+// Finished marks the current passage as typed
+func (tg *TextGenerator) Finished() {
+	tg.pos = tg.end
+}
+
+// Position returns the word the next session should start at
+func (tg *TextGenerator) Position() int {
+	return tg.pos
+}
+
+// SetPosition continues the text from a position saved earlier
+func (tg *TextGenerator) SetPosition(pos int) {
+	if pos < 0 || pos >= len(tg.words) {
+		pos = 0
+	}
+	tg.pos, tg.end = pos, pos
+}
+
+// Progress returns the position as a percentage of the text
+func (tg *TextGenerator) Progress() float64 {
+	return float64(tg.pos) / float64(len(tg.words)) * 100
+}
@@ -0,0 +1,62 @@
+package game
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestStripMarkdown(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"# Title", "Title"},
+		{"Some **bold**, *italic* and __strong__ text.", "Some bold, italic and strong text."},
+		{"***Both*** at once", "Both at once"},
+		{"2 * 3 * 4 is 24", "2 * 3 * 4 is 24"},
+		{"snake_case_name stays", "snake_case_name stays"},
+		{"Run `make test` first.", "Run make test first."},
+		{"See [the docs](https://example.com) and ![logo](logo.png).", "See the docs and logo."},
+		{"> Quoted line", "Quoted line"},
+		{"- item\n* other\n1. first\n- [x] done", "item\nother\nfirst\ndone"},
+		{"Before\n```go\nfunc main() {}\n```\nAfter", "Before\n\nAfter"},
+		{"Text<br>more", "Textmore"},
+		{"[ref]: https://example.com", ""},
+		{"---", ""},
+	}
+	for _, tt := range tests {
+		if got := stripMarkdown(tt.in); got != tt.want {
+			t.Errorf("stripMarkdown(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestTextGenerate(t *testing.T) {
+	tg := &TextGenerator{
+		words:     strings.Fields("One two three. Four five six seven. Eight."),
+		wordCount: 2,
+	}
+	// Passages run on to a near sentence end and start over after the text
+	want := []string{"One two three.", "Four five", "six seven.", "Eight.", "One two three."}
+	for _, w := range want {
+		if got := tg.Generate(); got != w {
+			t.Fatalf("Generate() = %q, want %q", got, w)
+		}
+	}
+
+	tg.SetPosition(3)
+	if got := tg.Generate(); got != "Four five" {
+		t.Errorf("Generate() after SetPosition(3) = %q, want %q", got, "Four five")
+	}
+	tg.SetPosition(100)
+	if tg.Position() != 0 {
+		t.Errorf("SetPosition(100) left position %d, want 0", tg.Position())
+	}
+}
+
+func TestNormalizeText(t *testing.T) {
+	in := "“Don’t” – she said… twice — ok"
+	want := `"Don't" - she said... twice - ok`
+	if got := normalizeText(in); got != want {
+		t.Errorf("normalizeText(%q) = %q, want %q", in, got, want)
+	}
+}
@@ -0,0 +1,100 @@
+package game
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// Zen rounds have no target and last until ESC or the end of the task
+var zenRound = Round{UntilTask: true, Zen: true}
+
+// handleZenInput accepts every keystroke as typed
+func (g *Game) handleZenInput() {
+	ev := g.screen.PollEvent()
+	if g.roundOver() {
+		g.endRound()
+		return
+	}
+	switch ev := ev.(type) {
+	case *tcell.EventKey:
+		switch ev.Key() {
+		case tcell.KeyEscape:
+			g.endRound()
+		case tcell.KeyRune:
+			g.zenType(ev.Rune())
+		case tcell.KeyTab:
+			g.zenType('\t')
+		case tcell.KeyEnter:
+			g.zenType('\n')
+		case tcell.KeyBackspace, tcell.KeyBackspace2:
+			if n := len(g.userInput); n > 0 {
+				g.userInput = g.userInput[:n-1]
+				g.currentChars = g.currentChars[:n-1]
+			}
+		}
+	}
+}
+
+// zenType adds a character to the free text, timing the gap since the last one
+func (g *Game) zenType(r rune) {
+	g.userInput = append(g.userInput, r)
+	g.currentChars = append(g.currentChars, CharacterState{char: r, typed: true, correct: true})
+	g.stats.totalStrokes++
+	g.stats.wordsTyped = len(strings.Fields(string(g.userInput)))
+
+	if !g.lastStroke.IsZero() {
+		if gap := time.Since(g.lastStroke); gap < maxKeyLatency {
+			g.keyGaps = append(g.keyGaps, gap)
+		}
+	}
+	g.lastStroke = time.Now()
+}
+
+// rhythm returns the average gap between keystrokes in milliseconds and how
+// much the gaps vary around it, as a percentage. Steady typing varies little.
+func (g *Game) rhythm() (float64, float64) {
+	if len(g.keyGaps) < 2 {
+		return 0, 0
+	}
+	var sum float64
+	for _, gap := range g.keyGaps {
+		sum += float64(gap.Milliseconds())
+	}
+	mean := sum / float64(len(g.keyGaps))
+	if mean == 0 {
+		return 0, 0
+	}
+	var squares float64
+	for _, gap := range g.keyGaps {
+		d := float64(gap.Milliseconds()) - mean
+		squares += d * d
+	}
+	return mean, math.Sqrt(squares/float64(len(g.keyGaps))) / mean * 100
+}
+
+// saveZenText writes the text of the zen round to a new file in ZenDir
+func (g *Game) saveZenText() (string, error) {
+	if err := os.MkdirAll(g.ZenDir, 0o755); err != nil {
+		return "", err
+	}
+	path := filepath.Join(g.ZenDir, "zen-"+g.stats.startTime.Format("20060102-150405")+".txt")
+	text := strings.TrimRight(string(g.userInput), "\n") + "\n"
+	if err := os.WriteFile(path, []byte(text), 0o644); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// zenResult describes the rhythm for the results screen
+func zenResult(r *Results) string {
+	if r.RhythmMs == 0 {
+		return "Rhythm: not enough keystrokes"
+	}
+	return fmt.Sprintf("Rhythm: %.0f ms between keys, varying by %.0f%%", r.RhythmMs, r.RhythmVariation)
+}
@@ -1,18 +1,29 @@
 package golang
 
+// Templates use {var} for a variable, {Var} for an exported name, {type} for
+// a type and {value} for a value, preferably of the last type placed
 var (
 	Templates = []string{
-		"func %s() %s {",
-		"var %s %s = %s",
-		"type %s struct { %s %s }",
-		"if %s != nil { return %s }",
-		"for %s := range %s {",
-		"switch %s := %s.(type) {",
-		"map[%s]%s{%s: %s}",
-		"func (%s *%s) %s() %s {",
+		"func {var}() {type} {",
+		"var {var} {type} = {value}",
+		"type {Var} struct { {var} {type} }",
+		"if {var} != nil { return {var} }",
+		"for {var} := range {var} {",
+		"switch {var} := {var}.(type) {",
+		"map[{type}]{type}{{value}: {value}}",
+		"func ({var} *{Var}) {Var}() {type} {",
 	}
 
 	Variables  = []string{"err", "val", "data", "result", "item", "obj", "ctx"}
 	Types      = []string{"string", "int", "bool", "error", "interface{}"}
 	Operations = []string{"nil", "true", "false", "0", "1", "\"\""}
+
+	// Values that fit each of the Types
+	Values = map[string][]string{
+		"string":      {"\"\"", "\"ok\"", "\"data\""},
+		"int":         {"0", "1", "42"},
+		"bool":        {"true", "false"},
+		"error":       {"nil"},
+		"interface{}": {"nil"},
+	}
 )
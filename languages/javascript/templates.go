@@ -1,18 +1,29 @@
 package javascript
 
+// Templates use {var} for a variable, {Var} for a class name, {type} for a
+// type and {value} for a value, preferably of the last type placed
 var (
 	Templates = []string{
-		"function %s(%s) {",
-		"const %s = %s",
-		"let %s = %s",
-		"class %s extends %s {",
-		"if (%s === %s) {",
-		"for (let %s of %s) {",
-		"async function %s(%s) {",
-		"try { %s } catch(%s) {",
+		"function {var}({var}) {",
+		"const {var} = {value}",
+		"let {var} = {value}",
+		"class {Var} extends {type} {",
+		"if ({var} === {value}) {",
+		"for (let {var} of {var}) {",
+		"async function {var}({var}) {",
+		"try { {var} } catch({var}) {",
 	}
 
 	Variables  = []string{"err", "data", "result", "item", "obj", "ctx", "response"}
 	Types      = []string{"Array", "Object", "string", "number", "boolean"}
 	Operations = []string{"null", "undefined", "true", "false", "0", "''", "[]", "{}"}
+
+	// Values that fit each of the Types
+	Values = map[string][]string{
+		"Array":   {"[]", "[1, 2]"},
+		"Object":  {"{}", "null"},
+		"string":  {"''", "'ok'"},
+		"number":  {"0", "1", "42"},
+		"boolean": {"true", "false"},
+	}
 )
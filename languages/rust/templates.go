@@ -1,17 +1,28 @@
 package rust
 
+// Templates use {var} for a variable, {Var} for a type name, {type} for a
+// type and {value} for a value, preferably of the last type placed
 var (
 	Templates = []string{
-		"fn %s(%s: %s) -> %s {",
-		"let mut %s: %s = %s;",
-		"struct %s<%s> { %s: %s }",
-		"impl %s for %s {",
-		"match %s {",
-		"if let Some(%s) = %s {",
-		"pub fn %s(&self) -> Result<%s, %s> {",
+		"fn {var}({var}: {type}) -> {type} {",
+		"let mut {var}: {type} = {value};",
+		"struct {Var}<{Var}> { {var}: {type} }",
+		"impl {Var} for {Var} {",
+		"match {var} {",
+		"if let Some({var}) = {var} {",
+		"pub fn {var}(&self) -> Result<{type}, {type}> {",
 	}
 
 	Variables  = []string{"err", "val", "data", "result", "item", "cfg", "ctx"}
 	Types      = []string{"String", "i32", "bool", "Option", "Result", "Vec"}
 	Operations = []string{"None", "Some", "Ok", "Err", "true", "false", "0", "\"\""}
+
+	// Values that fit each of the Types
+	Values = map[string][]string{
+		"String": {"String::new()", "\"ok\".to_string()"},
+		"i32":    {"0", "1", "42"},
+		"bool":   {"true", "false"},
+		"Option": {"None"},
+		"Vec":    {"Vec::new()", "vec![]"},
+	}
 )
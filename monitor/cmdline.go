@@ -0,0 +1,311 @@
+package monitor
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// SplitCommandLine splits s into words the way a POSIX shell would, honouring
+// single and double quotes and backslash escapes. Nothing is expanded.
+func SplitCommandLine(s string) ([]string, error) {
+	var words []string
+	var word strings.Builder
+	inWord := false
+	var quote rune
+
+	runes := []rune(s)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case quote == '"':
+			switch {
+			case r == '"':
+				quote = 0
+			case r == '\\' && i+1 < len(runes) && strings.ContainsRune("\"\\$`\n", runes[i+1]):
+				i++
+				word.WriteRune(runes[i])
+			default:
+				word.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inWord = true
+		case r == '\\':
+			if i+1 < len(runes) {
+				i++
+				if runes[i] != '\n' { // Line continuation
+					word.WriteRune(runes[i])
+					inWord = true
+				}
+			}
+		case r == ' ' || r == '\t' || r == '\n':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated %c quote", quote)
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
+
+// QuoteArgs joins argv into a command line the shell would split back into
+// the same words
+func QuoteArgs(argv []string) string {
+	quoted := make([]string, len(argv))
+	for i, arg := range argv {
+		quoted[i] = quoteArg(arg)
+	}
+	return strings.Join(quoted, " ")
+}
+
+func quoteArg(arg string) string {
+	if arg == "" {
+		return "''"
+	}
+	if !strings.ContainsAny(arg, " \t\n'\"\\$`|&;<>()*?[]{}~#!") {
+		return arg
+	}
+	return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+}
+
+// CommandLine is a command split into the wrappers in front of it and the
+// command that actually does the work
+type CommandLine struct {
+	Argv      []string // The command as given
+	Wrappers  []string // Wrapper invocations, e.g. "sudo -E" or "npx --yes"
+	Env       []string // NAME=value assignments found along the way
+	Effective []string // The command the wrappers end up running
+	Offset    int      // Index of Effective in Argv, -1 when it came from a "sh -c" script
+}
+
+// Wrappers and their options that take a separate value
+var wrapperOptions = map[string][]string{
+	"sudo":    {"-u", "-g", "-p", "-C", "-D", "-r", "-t", "-U", "-T", "-h", "--user", "--group", "--prompt", "--chdir", "--role", "--type", "--other-user", "--host"},
+	"doas":    {"-u", "-C"},
+	"env":     {"-u", "-C", "--unset", "--chdir"},
+	"time":    {"-f", "-o", "--format", "--output"},
+	"nice":    {"-n", "--adjustment"},
+	"ionice":  {"-c", "-n", "-p", "--class", "--classdata"},
+	"nohup":   {},
+	"stdbuf":  {"-i", "-o", "-e"},
+	"timeout": {"-s", "-k", "--signal", "--kill-after"},
+	"command": {},
+	"exec":    {"-a"},
+	"npx":     {"-p", "--package", "-c", "--call"},
+	"bunx":    {"-p", "--package"},
+}
+
+// Two-word wrappers that run a package binary
+var packageRunners = map[string]bool{
+	"npm exec":  true,
+	"pnpm dlx":  true,
+	"pnpm exec": true,
+	"yarn dlx":  true,
+}
+
+var shells = map[string]bool{"sh": true, "bash": true, "zsh": true, "dash": true}
+
+// ParseCommandLine strips known wrappers such as sudo, env, time, npx and
+// "bash -c" from argv to find the effective command
+func ParseCommandLine(argv []string) CommandLine {
+	cl := CommandLine{Argv: argv}
+	rest, offset := argv, 0
+
+	for len(rest) > 0 {
+		name := filepath.Base(rest[0])
+
+		// Leading NAME=value assignments
+		if IsAssignment(rest[0]) {
+			cl.Env = append(cl.Env, rest[0])
+			rest, offset = rest[1:], offset+1
+			continue
+		}
+
+		if len(rest) > 1 && packageRunners[name+" "+rest[1]] {
+			n := 2 + countOptions(rest[2:], nil)
+			cl.Wrappers = append(cl.Wrappers, strings.Join(rest[:n], " "))
+			rest, offset = rest[n:], offset+n
+			continue
+		}
+
+		if shells[name] {
+			if script, n, ok := shellScript(rest); ok {
+				cl.Wrappers = append(cl.Wrappers, strings.Join(rest[:n-1], " "))
+				inner := ParseCommandLine(script)
+				cl.Wrappers = append(cl.Wrappers, inner.Wrappers...)
+				cl.Env = append(cl.Env, inner.Env...)
+				cl.Effective = inner.Effective
+				cl.Offset = -1
+				return cl
+			}
+			break
+		}
+
+		valueOpts, ok := wrapperOptions[name]
+		if !ok {
+			break
+		}
+		n := 1 + countOptions(rest[1:], valueOpts)
+		// env takes assignments after its options
+		if name == "env" {
+			for n < len(rest) && IsAssignment(rest[n]) {
+				cl.Env = append(cl.Env, rest[n])
+				n++
+			}
+		}
+		// timeout's first operand is the duration
+		if name == "timeout" && n < len(rest) {
+			n++
+		}
+		cl.Wrappers = append(cl.Wrappers, strings.Join(rest[:n], " "))
+		rest, offset = rest[n:], offset+n
+	}
+
+	cl.Effective = rest
+	cl.Offset = offset
+	return cl
+}
+
+// countOptions returns how many leading words of args are options, counting
+// the values of options listed in valueOpts
+func countOptions(args []string, valueOpts []string) int {
+	n := 0
+	for n < len(args) {
+		arg := args[n]
+		if arg == "--" {
+			return n + 1
+		}
+		if !strings.HasPrefix(arg, "-") || arg == "-" {
+			return n
+		}
+		n++
+		for _, opt := range valueOpts {
+			if arg == opt {
+				n++ // Skip the option's value
+				break
+			}
+		}
+	}
+	return min(n, len(args))
+}
+
+// shellScript extracts the words of the first command in "sh -c 'script'"
+func shellScript(argv []string) ([]string, int, bool) {
+	for i := 1; i < len(argv); i++ {
+		arg := argv[i]
+		if !strings.HasPrefix(arg, "-") {
+			return nil, 0, false
+		}
+		if strings.HasPrefix(arg, "--") || !strings.Contains(arg, "c") {
+			continue // Other shell options such as -l or -e
+		}
+		if i+1 >= len(argv) {
+			return nil, 0, false
+		}
+		words, err := SplitCommandLine(argv[i+1])
+		if err != nil {
+			return nil, 0, false
+		}
+		return mainCommand(words), i + 2, true
+	}
+	return nil, 0, false
+}
+
+// Commands that only prepare the shell for the real work
+var setupCommands = map[string]bool{"cd": true, "export": true, "set": true, "source": true, ".": true}
+
+// mainCommand returns the first command of a script that is not a setup
+// command like cd, so "cd app && npm install" yields "npm install"
+func mainCommand(words []string) []string {
+	var cmd []string
+	for _, w := range words {
+		end := false
+		switch w {
+		case ";", "&&", "||", "|", "&":
+			end = true
+		default:
+			if strings.HasSuffix(w, ";") {
+				end = true
+				if w = strings.TrimSuffix(w, ";"); w != "" {
+					cmd = append(cmd, w)
+				}
+			} else {
+				cmd = append(cmd, w)
+			}
+		}
+
+		if end {
+			if len(cmd) > 0 && !setupCommands[cmd[0]] {
+				return cmd
+			}
+			cmd = nil
+		}
+	}
+	return cmd
+}
+
+// IsAssignment reports whether word is a shell variable assignment like FOO=1
+func IsAssignment(word string) bool {
+	name, _, ok := strings.Cut(word, "=")
+	if !ok || name == "" {
+		return false
+	}
+	for i, r := range name {
+		if r != '_' && !(r >= 'A' && r <= 'Z') && !(r >= 'a' && r <= 'z') && (i == 0 || !(r >= '0' && r <= '9')) {
+			return false
+		}
+	}
+	return true
+}
+
+// Display shows the wrappers and the effective command, e.g.
+// "sudo -E → docker pull nginx"
+func (cl CommandLine) Display() string {
+	parts := append([]string{}, cl.Wrappers...)
+	return strings.Join(append(parts, QuoteArgs(cl.Effective)), " → ")
+}
+
+// Replace returns Argv with the effective command swapped for effective. As
+// sudo and doas reset the environment, env is passed through "env" right
+// behind the last of them, e.g. "time sudo env CI=1 apt ..."; otherwise it is
+// returned for the caller to set. Replace fails for commands that came from a
+// "sh -c" script.
+func (cl CommandLine) Replace(effective, env []string) ([]string, []string, bool) {
+	if cl.Offset < 0 {
+		return nil, nil, false
+	}
+	argv := append([]string{}, cl.Argv[:cl.Offset]...)
+
+	if len(env) > 0 {
+		for i := len(argv) - 1; i >= 0; i-- {
+			name := filepath.Base(argv[i])
+			if name != "sudo" && name != "doas" {
+				continue
+			}
+			n := i + 1 + countOptions(argv[i+1:], wrapperOptions[name])
+			withEnv := append(append([]string{}, argv[:n]...), "env")
+			withEnv = append(append(withEnv, env...), argv[n:]...)
+			argv, env = withEnv, nil
+			break
+		}
+	}
+	return append(argv, effective...), env, true
+}
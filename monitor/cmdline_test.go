@@ -0,0 +1,71 @@
+package monitor
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseCommandLine(t *testing.T) {
+	tests := []struct {
+		cmd       string
+		wrappers  string // Joined with "|"
+		env       string
+		effective string
+		offset    int
+	}{
+		{"npm install", "", "", "npm install", 0},
+		{"env FOO=1 npm install", "env FOO=1", "FOO=1", "npm install", 2},
+		{"time go mod download", "time", "", "go mod download", 1},
+		{"npx --yes create-next-app my-app", "npx --yes", "", "create-next-app my-app", 2},
+		{"sudo -u root -E docker pull nginx", "sudo -u root -E", "", "docker pull nginx", 4},
+		{"timeout 10m nice -n 5 make", "timeout 10m|nice -n 5", "", "make", 5},
+		{"pnpm dlx create-vite app", "pnpm dlx", "", "create-vite app", 2},
+		{"bash -c 'cd app && cargo build'", "bash -c", "", "cargo build", -1},
+		{"sh -c 'cd web; npm ci; npm run build'", "sh -c", "", "npm ci", -1},
+	}
+	for _, tt := range tests {
+		argv, err := SplitCommandLine(tt.cmd)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cl := ParseCommandLine(argv)
+		got := []string{strings.Join(cl.Wrappers, "|"), strings.Join(cl.Env, " "), strings.Join(cl.Effective, " ")}
+		want := []string{tt.wrappers, tt.env, tt.effective}
+		if strings.Join(got, "/") != strings.Join(want, "/") || cl.Offset != tt.offset {
+			t.Errorf("ParseCommandLine(%q) = wrappers %q env %q effective %q offset %d, want %q %q %q %d",
+				tt.cmd, got[0], got[1], got[2], cl.Offset, tt.wrappers, tt.env, tt.effective, tt.offset)
+		}
+	}
+}
+
+func TestReplace(t *testing.T) {
+	env := []string{"DEBIAN_FRONTEND=noninteractive"}
+	tests := []struct {
+		cmd     string
+		want    string
+		wantEnv []string // Environment left for the caller to set
+		ok      bool
+	}{
+		{"apt install htop", "apt install -y htop", env, true},
+		{"sudo apt install htop", "sudo env DEBIAN_FRONTEND=noninteractive apt install -y htop", nil, true},
+		{"sudo -u root apt install htop", "sudo -u root env DEBIAN_FRONTEND=noninteractive apt install -y htop", nil, true},
+		{"time sudo apt install htop", "time sudo env DEBIAN_FRONTEND=noninteractive apt install -y htop", nil, true},
+		{"nice -n 5 doas apt install htop", "nice -n 5 doas env DEBIAN_FRONTEND=noninteractive apt install -y htop", nil, true},
+		{"sh -c 'apt install htop'", "", nil, false},
+	}
+	for _, tt := range tests {
+		argv, err := SplitCommandLine(tt.cmd)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cl := ParseCommandLine(argv)
+		got, gotEnv, ok := cl.Replace([]string{"apt", "install", "-y", "htop"}, env)
+		if ok != tt.ok {
+			t.Errorf("Replace(%q) ok = %v, want %v", tt.cmd, ok, tt.ok)
+			continue
+		}
+		if strings.Join(got, " ") != tt.want || strings.Join(gotEnv, " ") != strings.Join(tt.wantEnv, " ") {
+			t.Errorf("Replace(%q) = %q env %q, want %q env %q", tt.cmd, got, gotEnv, tt.want, tt.wantEnv)
+		}
+	}
+}
@@ -1,6 +1,9 @@
 package monitor
 
-import "strings"
+import (
+	"fmt"
+	"strings"
+)
 
 type CommandType int
 
@@ -10,49 +13,270 @@ const (
 	Kubernetes
 	NPM
 	Go
+	Cargo
+	Python
+	Java
+	Make
+	Bazel
+	Terraform
+	Helm
+	SystemPackages
+	Homebrew
 )
 
-var commonCommands = map[string]struct {
+var commandTypeNames = map[CommandType]string{
+	Generic:    "generic",
+	Docker:     "docker",
+	Kubernetes: "kubernetes",
+	NPM:        "npm",
+	Go:         "go",
+
+	Cargo:          "cargo",
+	Python:         "python",
+	Java:           "java",
+	Make:           "make",
+	Bazel:          "bazel",
+	Terraform:      "terraform",
+	Helm:           "helm",
+	SystemPackages: "system",
+	Homebrew:       "homebrew",
+}
+
+func (c CommandType) String() string {
+	if name, ok := commandTypeNames[c]; ok {
+		return name
+	}
+	return fmt.Sprintf("CommandType(%d)", int(c))
+}
+
+// ParseCommandType returns the CommandType with the given name
+func ParseCommandType(name string) (CommandType, error) {
+	for t, n := range commandTypeNames {
+		if strings.EqualFold(n, name) {
+			return t, nil
+		}
+	}
+	return Generic, fmt.Errorf("unknown command type %q", name)
+}
+
+func (c CommandType) MarshalText() ([]byte, error) {
+	return []byte(c.String()), nil
+}
+
+func (c *CommandType) UnmarshalText(text []byte) error {
+	t, err := ParseCommandType(string(text))
+	if err != nil {
+		return err
+	}
+	*c = t
+	return nil
+}
+
+// Detection is the result of classifying a command line
+type Detection struct {
 	Type        CommandType
 	Description string
-}{
-	"docker pull":      {Docker, "Pulling Docker image"},
-	"docker build":     {Docker, "Building Docker image"},
-	"kubectl apply":    {Kubernetes, "Applying Kubernetes manifests"},
-	"eksctl create":    {Kubernetes, "Creating EKS cluster"},
-	"npm install":      {NPM, "Installing NPM packages"},
-	"yarn install":     {NPM, "Installing Yarn packages"},
-	"go mod download":  {Go, "Downloading Go dependencies"},
-	"create-next-app": {NPM, "Creating Next.js application"},
-}
-
-var interactiveCommands = map[string]struct {
-	Description string
-	ArgExample  string
-}{
-	"create-next-app": {
-		Description: "Next.js app creation requires interactive input",
-		ArgExample:  "npx create-next-app@latest my-app --typescript --tailwind --eslint --app",
+	Interactive bool
+	Suggestion  string      // Non-interactive alternative for interactive commands
+	Rule        *Rule       // Rule that matched, nil for unknown commands
+	Command     CommandLine // The command with its wrappers separated
+}
+
+var builtinRules = []Rule{
+	// Interactive commands and the flags that make them safe to run unattended
+	{Pattern: "create-next-app", Type: NPM, Interactive: true,
+		SafeFlags:   []string{"--yes", "--typescript", "--ts", "--javascript", "--js"},
+		Description: "Creating Next.js application",
+		Suggestion:  "npx create-next-app@latest my-app --typescript --tailwind --eslint --app",
+		Rewrite:     &Rewrite{Args: []string{"--yes"}, Env: []string{"CI=1", "npm_config_yes=true"}},
+	},
+	{Pattern: "npm init", Type: NPM, Interactive: true, SafeFlags: []string{"-y", "--yes"},
+		Description: "Initializing NPM package",
+		Suggestion:  "npm init -y",
+		Rewrite:     &Rewrite{Args: []string{"-y"}},
+	},
+	{Pattern: "pnpm create", Type: NPM, Interactive: true, SafeFlags: []string{"--yes"},
+		Description: "Creating project with pnpm",
+		Suggestion:  "pnpm create vite my-app --template react-ts",
+		Rewrite:     &Rewrite{Args: []string{"--yes"}, Env: []string{"CI=1"}},
+	},
+	{Pattern: "pip uninstall", Type: Python, Interactive: true, SafeFlags: []string{"-y", "--yes"},
+		Description: "Uninstalling Python packages",
+		Suggestion:  "pip uninstall -y <package>",
+		Rewrite:     &Rewrite{Args: []string{"-y"}},
+	},
+	{Pattern: "poetry init", Type: Python, Interactive: true, SafeFlags: []string{"-n", "--no-interaction"},
+		Description: "Initializing Poetry project",
+		Suggestion:  "poetry init --no-interaction --name my-app",
+		Rewrite:     &Rewrite{Args: []string{"--no-interaction"}},
 	},
-	"npm init": {
-		Description: "NPM init requires interactive input",
-		ArgExample:  "npm init -y",
+	{Pattern: "gradle init", Type: Java, Interactive: true, SafeFlags: []string{"--use-defaults"},
+		Description: "Initializing Gradle project",
+		Suggestion:  "gradle init --use-defaults --type java-application",
+		Rewrite:     &Rewrite{Args: []string{"--use-defaults"}},
 	},
+	{Pattern: "mvn archetype:generate", Type: Java, Interactive: true, SafeFlags: []string{"-B", "--batch-mode"},
+		Description: "Generating Maven project",
+		Suggestion:  "mvn archetype:generate -B -DgroupId=com.example -DartifactId=my-app",
+		Rewrite:     &Rewrite{Args: []string{"-B"}},
+	},
+	{Pattern: "terraform init", Type: Terraform, Interactive: true, SafeFlags: []string{"-input=false"},
+		Description: "Initializing Terraform",
+		Suggestion:  "terraform init -input=false",
+		Rewrite:     &Rewrite{Args: []string{"-input=false"}},
+	},
+	{Pattern: "terraform plan", Type: Terraform, Interactive: true, SafeFlags: []string{"-input=false"},
+		Description: "Planning Terraform changes",
+		Suggestion:  "terraform plan -input=false",
+		Rewrite:     &Rewrite{Args: []string{"-input=false"}},
+	},
+	{Pattern: "terraform apply", Type: Terraform, Interactive: true, SafeFlags: []string{"-auto-approve", "--auto-approve"},
+		Description: "Applying Terraform changes",
+		Suggestion:  "terraform apply -auto-approve",
+		Rewrite:     &Rewrite{Args: []string{"-auto-approve"}, Env: []string{"TF_INPUT=0"}},
+	},
+	{Pattern: "terraform destroy", Type: Terraform, Interactive: true, SafeFlags: []string{"-auto-approve", "--auto-approve"},
+		Description: "Destroying Terraform resources",
+		Suggestion:  "terraform destroy -auto-approve",
+		Rewrite:     &Rewrite{Args: []string{"-auto-approve"}, Env: []string{"TF_INPUT=0"}},
+	},
+	{Pattern: "apt install", Type: SystemPackages, Interactive: true, SafeFlags: []string{"-y", "--yes", "--assume-yes"},
+		Description: "Installing apt packages",
+		Suggestion:  "apt install -y <package>",
+		Rewrite:     &Rewrite{Args: []string{"-y"}, Env: []string{"DEBIAN_FRONTEND=noninteractive"}},
+	},
+	{Pattern: "apt-get install", Type: SystemPackages, Interactive: true, SafeFlags: []string{"-y", "--yes", "--assume-yes"},
+		Description: "Installing apt packages",
+		Suggestion:  "apt-get install -y <package>",
+		Rewrite:     &Rewrite{Args: []string{"-y"}, Env: []string{"DEBIAN_FRONTEND=noninteractive"}},
+	},
+	{Pattern: "apt upgrade", Type: SystemPackages, Interactive: true, SafeFlags: []string{"-y", "--yes", "--assume-yes"},
+		Description: "Upgrading apt packages",
+		Suggestion:  "apt upgrade -y",
+		Rewrite:     &Rewrite{Args: []string{"-y"}, Env: []string{"DEBIAN_FRONTEND=noninteractive"}},
+	},
+	{Pattern: "apt-get upgrade", Type: SystemPackages, Interactive: true, SafeFlags: []string{"-y", "--yes", "--assume-yes"},
+		Description: "Upgrading apt packages",
+		Suggestion:  "apt-get upgrade -y",
+		Rewrite:     &Rewrite{Args: []string{"-y"}, Env: []string{"DEBIAN_FRONTEND=noninteractive"}},
+	},
+	{Pattern: "apt full-upgrade", Type: SystemPackages, Interactive: true, SafeFlags: []string{"-y", "--yes", "--assume-yes"},
+		Description: "Upgrading apt packages",
+		Suggestion:  "apt full-upgrade -y",
+		Rewrite:     &Rewrite{Args: []string{"-y"}, Env: []string{"DEBIAN_FRONTEND=noninteractive"}},
+	},
+	{Pattern: "apt-get dist-upgrade", Type: SystemPackages, Interactive: true, SafeFlags: []string{"-y", "--yes", "--assume-yes"},
+		Description: "Upgrading apt packages",
+		Suggestion:  "apt-get dist-upgrade -y",
+		Rewrite:     &Rewrite{Args: []string{"-y"}, Env: []string{"DEBIAN_FRONTEND=noninteractive"}},
+	},
+	{Pattern: "apt remove", Type: SystemPackages, Interactive: true, SafeFlags: []string{"-y", "--yes", "--assume-yes"},
+		Description: "Removing apt packages",
+		Suggestion:  "apt remove -y <package>",
+		Rewrite:     &Rewrite{Args: []string{"-y"}, Env: []string{"DEBIAN_FRONTEND=noninteractive"}},
+	},
+	{Pattern: "apt-get remove", Type: SystemPackages, Interactive: true, SafeFlags: []string{"-y", "--yes", "--assume-yes"},
+		Description: "Removing apt packages",
+		Suggestion:  "apt-get remove -y <package>",
+		Rewrite:     &Rewrite{Args: []string{"-y"}, Env: []string{"DEBIAN_FRONTEND=noninteractive"}},
+	},
+	{Pattern: "apt purge", Type: SystemPackages, Interactive: true, SafeFlags: []string{"-y", "--yes", "--assume-yes"},
+		Description: "Purging apt packages",
+		Suggestion:  "apt purge -y <package>",
+		Rewrite:     &Rewrite{Args: []string{"-y"}, Env: []string{"DEBIAN_FRONTEND=noninteractive"}},
+	},
+	{Pattern: "apt-get purge", Type: SystemPackages, Interactive: true, SafeFlags: []string{"-y", "--yes", "--assume-yes"},
+		Description: "Purging apt packages",
+		Suggestion:  "apt-get purge -y <package>",
+		Rewrite:     &Rewrite{Args: []string{"-y"}, Env: []string{"DEBIAN_FRONTEND=noninteractive"}},
+	},
+	{Pattern: "dnf install", Type: SystemPackages, Interactive: true, SafeFlags: []string{"-y", "--assumeyes"},
+		Description: "Installing dnf packages",
+		Suggestion:  "dnf install -y <package>",
+		Rewrite:     &Rewrite{Args: []string{"-y"}},
+	},
+	{Pattern: "dnf upgrade", Type: SystemPackages, Interactive: true, SafeFlags: []string{"-y", "--assumeyes"},
+		Description: "Upgrading dnf packages",
+		Suggestion:  "dnf upgrade -y",
+		Rewrite:     &Rewrite{Args: []string{"-y"}},
+	},
+	{Pattern: "dnf remove", Type: SystemPackages, Interactive: true, SafeFlags: []string{"-y", "--assumeyes"},
+		Description: "Removing dnf packages",
+		Suggestion:  "dnf remove -y <package>",
+		Rewrite:     &Rewrite{Args: []string{"-y"}},
+	},
+
+	{Pattern: "docker pull", Type: Docker, Description: "Pulling Docker image"},
+	{Pattern: "docker build", Type: Docker, Description: "Building Docker image"},
+	{Pattern: "docker compose up", Type: Docker, Description: "Starting Docker Compose services"},
+	{Pattern: "docker compose build", Type: Docker, Description: "Building Docker Compose services"},
+	{Pattern: "docker compose pull", Type: Docker, Description: "Pulling Docker Compose images"},
+	{Pattern: "docker-compose up", Type: Docker, Description: "Starting Docker Compose services"},
+	{Pattern: "docker-compose build", Type: Docker, Description: "Building Docker Compose services"},
+	{Pattern: "kubectl apply", Type: Kubernetes, Description: "Applying Kubernetes manifests"},
+	{Pattern: "eksctl create", Type: Kubernetes, Description: "Creating EKS cluster"},
+	{Pattern: "helm install", Type: Helm, Description: "Installing Helm chart"},
+	{Pattern: "helm upgrade", Type: Helm, Description: "Upgrading Helm release"},
+	{Pattern: "helm dependency update", Type: Helm, Description: "Updating Helm chart dependencies"},
+	{Pattern: "npm install", Type: NPM, Description: "Installing NPM packages"},
+	{Pattern: "npm ci", Type: NPM, Description: "Installing NPM packages from lockfile"},
+	{Pattern: "npm run build", Type: NPM, Description: "Building NPM project"},
+	{Pattern: "yarn install", Type: NPM, Description: "Installing Yarn packages"},
+	{Pattern: "pnpm install", Type: NPM, Description: "Installing pnpm packages"},
+	{Pattern: "pnpm add", Type: NPM, Description: "Adding pnpm packages"},
+	{Pattern: "go mod download", Type: Go, Description: "Downloading Go dependencies"},
+	{Pattern: "go build", Type: Go, Description: "Building Go packages"},
+	{Pattern: "go test", Type: Go, Description: "Running Go tests"},
+	{Pattern: "go install", Type: Go, Description: "Installing Go binaries"},
+	{Pattern: "cargo build", Type: Cargo, Description: "Building Rust crate"},
+	{Pattern: "cargo test", Type: Cargo, Description: "Running Rust tests"},
+	{Pattern: "cargo install", Type: Cargo, Description: "Installing Rust binary"},
+	{Pattern: "cargo fetch", Type: Cargo, Description: "Fetching Rust dependencies"},
+	{Pattern: "pip install", Type: Python, Description: "Installing Python packages"},
+	{Pattern: "poetry install", Type: Python, Description: "Installing Poetry dependencies"},
+	{Pattern: "poetry add", Type: Python, Description: "Adding Poetry dependencies"},
+	{Pattern: "uv sync", Type: Python, Description: "Syncing uv environment"},
+	{Pattern: "uv pip install", Type: Python, Description: "Installing Python packages with uv"},
+	{Pattern: "uv add", Type: Python, Description: "Adding Python dependencies with uv"},
+	{Pattern: "gradle build", Type: Java, Description: "Building Gradle project"},
+	{Pattern: "./gradlew build", Type: Java, Description: "Building Gradle project"},
+	{Pattern: "mvn install", Type: Java, Description: "Installing Maven project"},
+	{Pattern: "mvn package", Type: Java, Description: "Packaging Maven project"},
+	{Pattern: "mvn test", Type: Java, Description: "Running Maven tests"},
+	{Pattern: "make", Type: Make, Description: "Running make"},
+	{Pattern: "bazel build", Type: Bazel, Description: "Building with Bazel"},
+	{Pattern: "bazel test", Type: Bazel, Description: "Running Bazel tests"},
+	{Pattern: "apt update", Type: SystemPackages, Description: "Updating apt package lists"},
+	{Pattern: "apt-get update", Type: SystemPackages, Description: "Updating apt package lists"},
+	{Pattern: "brew install", Type: Homebrew, Description: "Installing Homebrew packages"},
+	{Pattern: "brew upgrade", Type: Homebrew, Description: "Upgrading Homebrew packages"},
+	{Pattern: "brew update", Type: Homebrew, Description: "Updating Homebrew"},
 }
 
-func DetectCommand(cmd string) (CommandType, string, bool, string) {
-	// Check for interactive commands first
-	for pattern, info := range interactiveCommands {
-		if strings.Contains(cmd, pattern) {
-			return Generic, info.Description, true, info.ArgExample
-		}
+// DefaultRegistry holds only the built-in rules
+var DefaultRegistry = mustRegistry(NewRegistry(nil))
+
+// DetectCommand classifies a shell command line with the built-in rules
+func DetectCommand(cmd string) Detection {
+	argv, err := SplitCommandLine(cmd)
+	if err != nil {
+		argv = strings.Fields(cmd)
 	}
+	return DefaultRegistry.Detect(argv)
+}
 
-	// Check regular commands
-	for pattern, info := range commonCommands {
-		if strings.HasPrefix(cmd, pattern) {
-			return info.Type, info.Description, false, ""
+// Tools returns the programs the built-in rules know for commands of type t,
+// e.g. docker and docker-compose for Docker
+func Tools(t CommandType) []string {
+	var tools []string
+	seen := map[string]bool{}
+	for _, rule := range builtinRules {
+		fields := strings.Fields(rule.Pattern)
+		if rule.Type != t || len(fields) == 0 || seen[fields[0]] {
+			continue
 		}
+		seen[fields[0]] = true
+		tools = append(tools, fields[0])
 	}
-	return Generic, "Running command", false, ""
+	return tools
 }
@@ -0,0 +1,64 @@
+package monitor
+
+import (
+	"sort"
+	"time"
+
+	"github.com/parth/DevTyper/store"
+)
+
+// Runs remembered per command
+const maxHistoryRuns = 20
+
+// DurationHistory remembers how long commands took to run
+type DurationHistory struct {
+	path string
+	Runs map[string][]float64 `json:"runs"` // Seconds per run, keyed by HistoryKey
+}
+
+// LoadDurationHistory reads the history file at path. A missing file yields
+// an empty history.
+func LoadDurationHistory(path string) (*DurationHistory, error) {
+	h := &DurationHistory{path: path, Runs: map[string][]float64{}}
+	if err := store.Load(path, h); err != nil {
+		return nil, err
+	}
+	if h.Runs == nil {
+		h.Runs = map[string][]float64{}
+	}
+	return h, nil
+}
+
+// HistoryKey identifies a command in the history by its effective command,
+// so "sudo docker build ." and "docker build ." share their timings
+func HistoryKey(cl CommandLine) string {
+	return QuoteArgs(cl.Effective)
+}
+
+// Record adds a run of the command with the given key
+func (h *DurationHistory) Record(key string, d time.Duration) {
+	runs := append(h.Runs[key], d.Seconds())
+	if len(runs) > maxHistoryRuns {
+		runs = runs[len(runs)-maxHistoryRuns:]
+	}
+	h.Runs[key] = runs
+}
+
+// Expected returns the median duration of past runs and how many there were
+func (h *DurationHistory) Expected(key string) (time.Duration, int) {
+	runs := append([]float64{}, h.Runs[key]...)
+	if len(runs) == 0 {
+		return 0, 0
+	}
+	sort.Float64s(runs)
+	median := runs[len(runs)/2]
+	if len(runs)%2 == 0 {
+		median = (runs[len(runs)/2-1] + median) / 2
+	}
+	return time.Duration(median * float64(time.Second)), len(runs)
+}
+
+// Save writes the history back to its file
+func (h *DurationHistory) Save() error {
+	return store.Save(h.path, h)
+}
@@ -0,0 +1,100 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// How long hooks and notifier commands may run before they are killed
+var (
+	hookTimeout   = 30 * time.Second
+	notifyTimeout = 5 * time.Second
+)
+
+// Completion runs user hooks and notifiers once a task finishes
+type Completion struct {
+	OnComplete string // Shell command run when the task succeeds
+	OnFailure  string // Shell command run when the task fails
+	Notifiers  []Notifier
+
+	errMu  sync.Mutex
+	errors []error
+}
+
+// Attach registers the completion handlers on t
+func (c *Completion) Attach(t *Task) {
+	t.OnComplete(c.Handle)
+}
+
+// Handle runs the matching hook and sends notifications for a finished task
+func (c *Completion) Handle(t *Task) {
+	failed := t.Status() == TaskFailed
+
+	hook := c.OnComplete
+	title := "Task completed"
+	if failed {
+		hook = c.OnFailure
+		title = "Task failed"
+	}
+
+	if hook != "" {
+		if err := RunHook(hook, t); err != nil {
+			c.addError(fmt.Errorf("hook %q: %w", hook, err))
+		}
+	}
+
+	message := fmt.Sprintf("%s (%s)", t.DisplayCommand(), t.Duration().Round(time.Second))
+	for _, n := range c.Notifiers {
+		if err := n.Notify(title, message); err != nil {
+			c.addError(fmt.Errorf("notify: %w", err))
+		}
+	}
+}
+
+// Errors returns the hook and notifier failures collected so far
+func (c *Completion) Errors() []error {
+	c.errMu.Lock()
+	defer c.errMu.Unlock()
+	return append([]error{}, c.errors...)
+}
+
+func (c *Completion) addError(err error) {
+	c.errMu.Lock()
+	c.errors = append(c.errors, err)
+	c.errMu.Unlock()
+}
+
+// RunHook runs command through the shell with the task details in its
+// environment. Its output is discarded so it cannot disturb the game screen.
+func RunHook(command string, t *Task) error {
+	return runWithTimeout(hookTimeout, append(os.Environ(), HookEnv(t)...), "sh", "-c", command)
+}
+
+// runWithTimeout runs a program, killing it once timeout has passed. A nil
+// env inherits ours.
+func runWithTimeout(timeout time.Duration, env []string, name string, args ...string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Env = env
+	err := cmd.Run()
+	if ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("timed out after %s", timeout)
+	}
+	return err
+}
+
+// HookEnv describes a finished task as environment variables
+func HookEnv(t *Task) []string {
+	return []string{
+		"DEVTYPER_EXIT_CODE=" + strconv.Itoa(t.ExitCode()),
+		"DEVTYPER_DURATION=" + strconv.FormatFloat(t.Duration().Seconds(), 'f', 1, 64),
+		"DEVTYPER_COMMAND=" + t.DisplayCommand(),
+		"DEVTYPER_LOG=" + t.LogPath(),
+	}
+}
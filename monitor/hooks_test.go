@@ -0,0 +1,224 @@
+package monitor
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// recordingNotifier keeps what it was asked to show
+type recordingNotifier struct {
+	titles   []string
+	messages []string
+	err      error
+}
+
+func (n *recordingNotifier) Notify(title, message string) error {
+	n.titles = append(n.titles, title)
+	n.messages = append(n.messages, message)
+	return n.err
+}
+
+// runTask runs a command and its completion handlers to the end with c attached
+func runTask(t *testing.T, c *Completion, args ...string) *Task {
+	t.Helper()
+	task := NewTask(args[0], args[1:]...)
+	if c != nil {
+		c.Attach(task)
+	}
+	if err := task.Start(); err != nil {
+		t.Fatalf("starting %q: %v", args, err)
+	}
+	select {
+	case <-task.Done:
+	case <-time.After(10 * time.Second):
+		t.Fatalf("%q did not finish", args)
+	}
+	if !task.WaitHandlers(10 * time.Second) {
+		t.Fatalf("handlers of %q did not finish", args)
+	}
+	return task
+}
+
+func TestCompletionTitles(t *testing.T) {
+	tests := []struct {
+		args  []string
+		title string
+	}{
+		{[]string{"true"}, "Task completed"},
+		{[]string{"sh", "-c", "exit 3"}, "Task failed"},
+	}
+	for _, tt := range tests {
+		n := &recordingNotifier{}
+		c := &Completion{Notifiers: []Notifier{n}}
+		runTask(t, c, tt.args...)
+
+		if len(n.titles) != 1 || n.titles[0] != tt.title {
+			t.Errorf("%q notified %q, want [%q]", tt.args, n.titles, tt.title)
+			continue
+		}
+		if !strings.HasPrefix(n.messages[0], strings.Join(tt.args, " ")+" (") {
+			t.Errorf("%q notified message %q, want the command and duration", tt.args, n.messages[0])
+		}
+		if errs := c.Errors(); len(errs) != 0 {
+			t.Errorf("%q collected errors %v", tt.args, errs)
+		}
+	}
+}
+
+func TestCompletionHooks(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "hook")
+	c := &Completion{
+		OnComplete: "echo completed > " + out,
+		OnFailure:  `echo "failed $DEVTYPER_EXIT_CODE" > ` + out,
+	}
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"true"}, "completed\n"},
+		{[]string{"sh", "-c", "exit 3"}, "failed 3\n"},
+	}
+	for _, tt := range tests {
+		runTask(t, c, tt.args...)
+		data, err := os.ReadFile(out)
+		if err != nil {
+			t.Fatalf("%q: hook did not run: %v", tt.args, err)
+		}
+		if string(data) != tt.want {
+			t.Errorf("%q: hook wrote %q, want %q", tt.args, data, tt.want)
+		}
+	}
+}
+
+func TestCompletionErrors(t *testing.T) {
+	failing := &recordingNotifier{err: errors.New("no display")}
+	working := &recordingNotifier{}
+	c := &Completion{OnComplete: "exit 1", Notifiers: []Notifier{failing, working}}
+	runTask(t, c, "true")
+
+	errs := c.Errors()
+	if len(errs) != 2 {
+		t.Fatalf("Errors() = %v, want the hook and notifier failures", errs)
+	}
+	if !strings.Contains(errs[0].Error(), `hook "exit 1"`) {
+		t.Errorf("Errors()[0] = %v, want the hook failure", errs[0])
+	}
+	if !strings.Contains(errs[1].Error(), "no display") {
+		t.Errorf("Errors()[1] = %v, want the notifier failure", errs[1])
+	}
+	if len(working.titles) != 1 {
+		t.Errorf("a failing notifier stopped the next one")
+	}
+}
+
+func TestSlowHook(t *testing.T) {
+	defer func(d time.Duration) { hookTimeout = d }(hookTimeout)
+	hookTimeout = 500 * time.Millisecond
+
+	c := &Completion{OnComplete: "sleep 5"}
+	task := NewTask("true")
+	c.Attach(task)
+	start := time.Now()
+	if err := task.Start(); err != nil {
+		t.Fatal(err)
+	}
+	<-task.Done
+	if waited := time.Since(start); waited > 400*time.Millisecond {
+		t.Errorf("Done fired after %s, the hook held it up", waited)
+	}
+
+	if !task.WaitHandlers(3 * time.Second) {
+		t.Fatal("the hook was not killed at its timeout")
+	}
+	errs := c.Errors()
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), "timed out") {
+		t.Errorf("Errors() = %v, want the hook timing out", errs)
+	}
+}
+
+func TestHookEnv(t *testing.T) {
+	task := runTask(t, nil, "sh", "-c", "exit 3")
+	env := map[string]string{}
+	for _, kv := range HookEnv(task) {
+		name, value, _ := strings.Cut(kv, "=")
+		env[name] = value
+	}
+
+	want := map[string]string{
+		"DEVTYPER_EXIT_CODE": "3",
+		"DEVTYPER_COMMAND":   "sh -c exit 3",
+		"DEVTYPER_LOG":       "",
+	}
+	for name, value := range want {
+		if got, ok := env[name]; !ok || got != value {
+			t.Errorf("%s = %q, want %q", name, got, value)
+		}
+	}
+	if _, ok := env["DEVTYPER_DURATION"]; !ok {
+		t.Errorf("DEVTYPER_DURATION missing from %v", env)
+	}
+}
+
+func TestHookEnvRedacts(t *testing.T) {
+	r, err := NewRedactor(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	task := NewTask("env", "NPM_TOKEN=abcdef123456", "true")
+	task.SetRedactor(r)
+	if err := task.Start(); err != nil {
+		t.Fatal(err)
+	}
+	<-task.Done
+
+	for _, kv := range HookEnv(task) {
+		if strings.Contains(kv, "abcdef123456") {
+			t.Errorf("HookEnv leaks the token: %q", kv)
+		}
+	}
+}
+
+func TestTerminalNotifier(t *testing.T) {
+	tests := []struct {
+		osc  int
+		tmux string
+		want string
+	}{
+		{9, "", "\033]9;Task completed: make (3s)\a"},
+		{777, "", "\033]777;notify;Task completed;make (3s)\a"},
+		{9, "/tmp/tmux-1000/default,1,0", "\033Ptmux;\033\033]9;Task completed: make (3s)\a\033\\"},
+	}
+	for _, tt := range tests {
+		t.Setenv("TMUX", tt.tmux)
+		var out bytes.Buffer
+		n := TerminalNotifier{Out: &out, OSC: tt.osc}
+		if err := n.Notify("Task completed", "make (3s)"); err != nil {
+			t.Fatal(err)
+		}
+		if out.String() != tt.want {
+			t.Errorf("OSC %d with TMUX=%q wrote %q, want %q", tt.osc, tt.tmux, out.String(), tt.want)
+		}
+	}
+}
+
+func TestSanitizeOSC(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"plain text", "plain text"},
+		{"a;b", "a b"},
+		{"bell\aend", "bell end"},
+		{"esc\033]9;x", "esc ]9 x"},
+		{"line\nbreak\x7f", "line break "},
+	}
+	for _, tt := range tests {
+		if got := sanitizeOSC(tt.in); got != tt.want {
+			t.Errorf("sanitizeOSC(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
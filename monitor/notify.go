@@ -0,0 +1,78 @@
+package monitor
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Notifier delivers a short message when a task finishes
+type Notifier interface {
+	Notify(title, message string) error
+}
+
+// NotifySend shows a desktop notification through notify-send
+type NotifySend struct{}
+
+func (NotifySend) Notify(title, message string) error {
+	return runWithTimeout(notifyTimeout, nil, "notify-send", "--app-name=devtyper", title, message)
+}
+
+// TerminalNotifier writes an OSC 9 or OSC 777 escape sequence, which many
+// terminal emulators turn into a desktop notification
+type TerminalNotifier struct {
+	Out io.Writer
+	OSC int // 9 or 777
+}
+
+func (n TerminalNotifier) Notify(title, message string) error {
+	var seq string
+	if n.OSC == 777 {
+		seq = fmt.Sprintf("\033]777;notify;%s;%s\a", sanitizeOSC(title), sanitizeOSC(message))
+	} else {
+		seq = fmt.Sprintf("\033]9;%s: %s\a", sanitizeOSC(title), sanitizeOSC(message))
+	}
+
+	// Inside tmux the sequence has to be wrapped to reach the outer terminal
+	if os.Getenv("TMUX") != "" {
+		seq = "\033Ptmux;" + strings.ReplaceAll(seq, "\033", "\033\033") + "\033\\"
+	}
+	_, err := io.WriteString(n.Out, seq)
+	return err
+}
+
+// TmuxNotifier shows the message in the tmux status line of every client
+type TmuxNotifier struct{}
+
+func (TmuxNotifier) Notify(title, message string) error {
+	if os.Getenv("TMUX") == "" {
+		return nil // Not running inside tmux
+	}
+	return runWithTimeout(notifyTimeout, nil, "tmux", "display-message", title+": "+message)
+}
+
+// NewNotifier returns the built-in notifier with the given name
+func NewNotifier(name string, out io.Writer) (Notifier, error) {
+	switch name {
+	case "notify-send":
+		return NotifySend{}, nil
+	case "osc9":
+		return TerminalNotifier{Out: out, OSC: 9}, nil
+	case "osc777":
+		return TerminalNotifier{Out: out, OSC: 777}, nil
+	case "tmux":
+		return TmuxNotifier{}, nil
+	}
+	return nil, fmt.Errorf("unknown notifier %q", name)
+}
+
+// Control characters would terminate the escape sequence early
+func sanitizeOSC(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f || r == ';' {
+			return ' '
+		}
+		return r
+	}, s)
+}
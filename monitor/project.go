@@ -0,0 +1,179 @@
+package monitor
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Project describes the kind of project in a directory
+type Project struct {
+	Dir             string
+	Languages       []string // e.g. "go", "javascript", "rust", in order of precedence
+	PackageManagers []string // e.g. "npm", "cargo", "poetry"
+	Markers         []string // Files that gave the project away
+}
+
+// Marker files, in order of precedence, and what they tell us
+var projectMarkers = []struct {
+	File           string
+	Language       string
+	PackageManager string
+}{
+	{"go.mod", "go", "go"},
+	{"Cargo.toml", "rust", "cargo"},
+	{"tsconfig.json", "typescript", ""},
+	{"package.json", "javascript", ""},
+	{"pnpm-lock.yaml", "javascript", "pnpm"},
+	{"yarn.lock", "javascript", "yarn"},
+	{"package-lock.json", "javascript", "npm"},
+	{"bun.lockb", "javascript", "bun"},
+	{"pyproject.toml", "python", ""},
+	{"poetry.lock", "python", "poetry"},
+	{"uv.lock", "python", "uv"},
+	{"requirements.txt", "python", "pip"},
+	{"Dockerfile", "docker", "docker"},
+	{"docker-compose.yml", "docker", "docker"},
+	{"compose.yaml", "docker", "docker"},
+}
+
+// Display names for the project languages
+var projectLanguageNames = map[string]string{
+	"go":         "Go",
+	"rust":       "Rust",
+	"typescript": "TypeScript",
+	"javascript": "JavaScript",
+	"python":     "Python",
+	"terraform":  "Terraform",
+	"docker":     "Docker",
+}
+
+// DetectProject inspects the marker files in dir
+func DetectProject(dir string) Project {
+	p := Project{Dir: dir}
+	for _, m := range projectMarkers {
+		if _, err := os.Stat(filepath.Join(dir, m.File)); err != nil {
+			continue
+		}
+		p.Markers = append(p.Markers, m.File)
+		p.addLanguage(m.Language)
+		if m.PackageManager != "" {
+			p.addPackageManager(m.PackageManager)
+		}
+	}
+
+	// A package.json without a lockfile is most likely npm
+	if p.HasLanguage("javascript") && !p.hasAnyPackageManager("npm", "yarn", "pnpm", "bun") {
+		p.addPackageManager("npm")
+	}
+	if p.HasLanguage("python") && !p.hasAnyPackageManager("pip", "poetry", "uv") {
+		p.addPackageManager("pip")
+	}
+
+	if tf, _ := filepath.Glob(filepath.Join(dir, "*.tf")); len(tf) > 0 {
+		p.Markers = append(p.Markers, filepath.Base(tf[0]))
+		p.addLanguage("terraform")
+		p.addPackageManager("terraform")
+	}
+	return p
+}
+
+func (p *Project) addLanguage(lang string) {
+	if !p.HasLanguage(lang) {
+		p.Languages = append(p.Languages, lang)
+	}
+}
+
+func (p *Project) addPackageManager(pm string) {
+	if !p.hasAnyPackageManager(pm) {
+		p.PackageManagers = append(p.PackageManagers, pm)
+	}
+}
+
+// HasLanguage reports whether the project uses lang
+func (p Project) HasLanguage(lang string) bool {
+	for _, l := range p.Languages {
+		if l == lang {
+			return true
+		}
+	}
+	return false
+}
+
+func (p Project) hasAnyPackageManager(names ...string) bool {
+	for _, pm := range p.PackageManagers {
+		for _, name := range names {
+			if pm == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Describe names the project, e.g. "Go/Docker project", or "" if unknown
+func (p Project) Describe() string {
+	if len(p.Languages) == 0 {
+		return ""
+	}
+	names := make([]string, 0, len(p.Languages))
+	for _, lang := range p.Languages {
+		// TypeScript projects also have package.json, don't say both
+		if lang == "javascript" && p.HasLanguage("typescript") {
+			continue
+		}
+		names = append(names, projectLanguageNames[lang])
+	}
+	return strings.Join(names, "/") + " project"
+}
+
+// PracticeLanguage returns the languages pack best matching the project:
+// "go", "javascript", "rust", or "" when none fits
+func (p Project) PracticeLanguage() string {
+	for _, lang := range p.Languages {
+		switch lang {
+		case "go", "rust", "javascript":
+			return lang
+		case "typescript":
+			return "javascript"
+		}
+	}
+	return ""
+}
+
+// Languages packs suggested by the command itself
+var commandLanguages = map[CommandType]string{
+	Go:    "go",
+	Cargo: "rust",
+	NPM:   "javascript",
+}
+
+// TaskLanguage picks the languages pack to practice while a command of type t
+// runs in project p, and says what it was picked from. The command's own
+// language wins, so cargo in a mixed repository still practices Rust.
+func TaskLanguage(t CommandType, p Project) (lang, source string) {
+	if lang := commandLanguages[t]; lang != "" {
+		return lang, t.String()
+	}
+	if lang := p.PracticeLanguage(); lang != "" {
+		return lang, "project"
+	}
+	return "", ""
+}
+
+// Refine adds the project to the description of commands that say little
+// about themselves, like make or ./build.sh
+func (p Project) Refine(d Detection) Detection {
+	if d.Type != Generic && d.Type != Make {
+		return d
+	}
+	project := p.Describe()
+	if project == "" {
+		return d
+	}
+	if d.Rule == nil && len(d.Command.Effective) > 0 {
+		d.Description = "Running " + filepath.Base(d.Command.Effective[0])
+	}
+	d.Description += " in " + project
+	return d
+}
@@ -0,0 +1,88 @@
+package monitor
+
+import (
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const redacted = "[REDACTED]"
+
+type redactRule struct {
+	re   *regexp.Regexp
+	repl string // Replacement template, may keep a captured prefix
+}
+
+// Built-in secret patterns
+var builtinRedactRules = []redactRule{
+	// AWS access key IDs and secret keys in assignments
+	{regexp.MustCompile(`\b(AKIA|ASIA)[0-9A-Z]{16}\b`), redacted},
+	{regexp.MustCompile(`(?i)(aws_secret_access_key["']?\s*[=:]\s*["']?)[A-Za-z0-9/+=]{40}`), "${1}" + redacted},
+	// Authorization headers
+	{regexp.MustCompile(`(?i)(\bbearer\s+)[A-Za-z0-9\-._~+/]+=*`), "${1}" + redacted},
+	// npm and GitHub tokens
+	{regexp.MustCompile(`\bnpm_[A-Za-z0-9]{36}\b`), redacted},
+	{regexp.MustCompile(`(_authToken=)\S+`), "${1}" + redacted},
+	{regexp.MustCompile(`\bgh[pousr]_[A-Za-z0-9]{36,}\b`), redacted},
+	// Secret-looking assignments, e.g. NPM_TOKEN=... given on the command line
+	{regexp.MustCompile(`(?i)\b([A-Z0-9_]*(TOKEN|SECRET|PASSWORD)[A-Z0-9_]*\s*[=:]\s*)\S+`), "${1}" + redacted},
+	// Credentials embedded in URLs
+	{regexp.MustCompile(`([a-zA-Z][a-zA-Z0-9+.\-]*://)[^/\s:@]+:[^/\s@]+@`), "${1}" + redacted + "@"},
+}
+
+// Environment variables whose values are always hidden
+var secretEnvNames = []string{"TOKEN", "SECRET", "PASSWORD"}
+
+// Redactor masks secrets in task output before it is displayed or stored
+type Redactor struct {
+	rules    []redactRule
+	literals []string // Secret values taken from the environment
+}
+
+// NewRedactor returns a redactor with the built-in patterns, the values of
+// secret-looking environment variables and the extra user patterns
+func NewRedactor(patterns []string) (*Redactor, error) {
+	r := &Redactor{rules: append([]redactRule{}, builtinRedactRules...)}
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, err
+		}
+		r.rules = append(r.rules, redactRule{re, redacted})
+	}
+
+	for _, kv := range os.Environ() {
+		name, value, ok := strings.Cut(kv, "=")
+		if !ok || len(value) < 6 {
+			continue // Short values would mask ordinary words
+		}
+		upper := strings.ToUpper(name)
+		for _, s := range secretEnvNames {
+			if strings.Contains(upper, s) {
+				r.literals = append(r.literals, value)
+				break
+			}
+		}
+	}
+	// Replace longer values first so overlapping secrets are fully hidden
+	sort.Slice(r.literals, func(i, j int) bool {
+		return len(r.literals[i]) > len(r.literals[j])
+	})
+	return r, nil
+}
+
+// Redact returns s with every known secret replaced. A nil Redactor returns
+// s unchanged.
+func (r *Redactor) Redact(s string) string {
+	if r == nil {
+		return s
+	}
+	for _, value := range r.literals {
+		s = strings.ReplaceAll(s, value, redacted)
+	}
+	for _, rule := range r.rules {
+		s = rule.re.ReplaceAllString(s, rule.repl)
+	}
+	return s
+}
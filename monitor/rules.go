@@ -0,0 +1,301 @@
+package monitor
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// MatchKind selects how a rule pattern is compared with a command's argv
+type MatchKind string
+
+const (
+	MatchExact  MatchKind = "exact"  // argv is exactly the pattern words
+	MatchPrefix MatchKind = "prefix" // argv starts with the pattern words
+	MatchGlob   MatchKind = "glob"   // Like prefix, each pattern word is a glob
+	MatchRegex  MatchKind = "regex"  // Regular expression over the space-joined argv
+)
+
+// More specific kinds win when two rules match the same number of words
+var matchKindRank = map[MatchKind]int{
+	MatchExact:  3,
+	MatchPrefix: 2,
+	MatchGlob:   1,
+	MatchRegex:  0,
+}
+
+// Rewrite turns an interactive command into one that runs unattended
+type Rewrite struct {
+	Args []string `json:"args"` // Flags inserted after the matched command words
+	Env  []string `json:"env"`  // NAME=value pairs added to the environment
+}
+
+// Rule describes how to recognise a command and what to tell the user about it
+type Rule struct {
+	Pattern     string      `json:"pattern"`
+	Match       MatchKind   `json:"match"`
+	Type        CommandType `json:"type"`
+	Description string      `json:"description"`
+	Interactive bool        `json:"interactive"`
+	SafeFlags   []string    `json:"safe_flags"` // Flags that make an interactive command run unattended
+	Suggestion  string      `json:"suggestion"`
+	Rewrite     *Rewrite    `json:"rewrite"` // How to make an interactive command unattended
+	Source      string      `json:"-"`       // "built-in" or the config file the rule came from
+
+	words []string
+	re    *regexp.Regexp
+	order int // Registration order, user rules first
+}
+
+func (r *Rule) compile() error {
+	if r.Pattern == "" {
+		return fmt.Errorf("rule with empty pattern from %s", r.Source)
+	}
+	if r.Match == "" {
+		r.Match = MatchPrefix
+	}
+
+	switch r.Match {
+	case MatchExact, MatchPrefix:
+		r.words = strings.Fields(r.Pattern)
+	case MatchGlob:
+		r.words = strings.Fields(r.Pattern)
+		for _, w := range r.words {
+			if _, err := path.Match(w, ""); err != nil {
+				return fmt.Errorf("rule %q: %w", r.Pattern, err)
+			}
+		}
+	case MatchRegex:
+		re, err := regexp.Compile(r.Pattern)
+		if err != nil {
+			return fmt.Errorf("rule %q: %w", r.Pattern, err)
+		}
+		r.re = re
+	default:
+		return fmt.Errorf("rule %q: unknown match kind %q", r.Pattern, r.Match)
+	}
+
+	if r.Rewrite != nil {
+		for _, kv := range r.Rewrite.Env {
+			if !strings.Contains(kv, "=") {
+				return fmt.Errorf("rule %q: rewrite env %q is not NAME=value", r.Pattern, kv)
+			}
+		}
+	}
+
+	if r.Description == "" {
+		r.Description = "Running command"
+		if r.re == nil {
+			r.Description = "Running " + r.Pattern
+		}
+	}
+	return nil
+}
+
+// match returns how many argv words the rule covers, or 0 if it does not match
+func (r *Rule) match(argv []string) int {
+	switch r.Match {
+	case MatchExact, MatchPrefix, MatchGlob:
+		end := r.end(argv)
+		if end == 0 || (r.Match == MatchExact && end != len(argv)) {
+			return 0
+		}
+		return len(r.words)
+	case MatchRegex:
+		line := strings.Join(argv, " ")
+		loc := r.re.FindStringIndex(line)
+		if loc == nil {
+			return 0
+		}
+		return max(1, len(strings.Fields(line[loc[0]:loc[1]])))
+	}
+	return 0
+}
+
+// end returns the index in argv just past the last pattern word, or 0 if
+// argv doesn't start with the pattern words
+func (r *Rule) end(argv []string) int {
+	if r.Match == MatchGlob {
+		return matchWords(r.words, argv, func(p, w string) bool {
+			ok, _ := path.Match(p, w)
+			return ok
+		})
+	}
+	return matchWords(r.words, argv, wordEqual)
+}
+
+// matchWords returns the index in argv just past the words of pattern, or 0
+// if they don't match. Options between the words are skipped, so "docker
+// compose up" matches "docker compose -f dev.yml up".
+func matchWords(pattern, argv []string, eq func(p, w string) bool) int {
+	if len(pattern) == 0 {
+		return 0
+	}
+	i := 0
+	for j, p := range pattern {
+		if j > 0 {
+			i = skipOptions(argv, i, p, eq)
+		}
+		if i >= len(argv) {
+			return 0
+		}
+		w := argv[i]
+		// Let "docker" match "/usr/bin/docker"
+		if j == 0 && !strings.Contains(p, "/") {
+			w = filepath.Base(w)
+		}
+		if !eq(p, w) {
+			return 0
+		}
+		i++
+	}
+	return i
+}
+
+// skipOptions returns the index of the first word from argv[i] on that is
+// neither an option nor an option's value. A word after an option is taken
+// for its value unless it is the pattern word p looked for next.
+func skipOptions(argv []string, i int, p string, eq func(p, w string) bool) int {
+	for i < len(argv) && strings.HasPrefix(argv[i], "-") && argv[i] != "-" && argv[i] != "--" && !eq(p, argv[i]) {
+		opt := argv[i]
+		i++
+		if !strings.Contains(opt, "=") && i < len(argv) && !strings.HasPrefix(argv[i], "-") && !eq(p, argv[i]) {
+			i++
+		}
+	}
+	return i
+}
+
+// Words match exactly or as a versioned package, e.g. create-next-app@latest
+func wordEqual(p, w string) bool {
+	return w == p || (strings.HasPrefix(w, p+"@") && !strings.Contains(p, "@"))
+}
+
+// interactive reports whether argv will prompt, i.e. the rule is interactive
+// and none of its safe flags was given
+func (r *Rule) interactive(argv []string) bool {
+	if !r.Interactive {
+		return false
+	}
+	for _, arg := range argv {
+		for _, flag := range r.SafeFlags {
+			if arg == flag || strings.HasPrefix(arg, flag+"=") {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Apply returns argv with the rewrite's flags inserted after the words the
+// rule matched, or appended for regex rules
+func (r *Rule) Apply(argv []string) []string {
+	if r.Rewrite == nil {
+		return argv
+	}
+	at := len(argv)
+	if r.words != nil {
+		if end := r.end(argv); end > 0 {
+			at = end
+		}
+	}
+	rewritten := append([]string{}, argv[:at]...)
+	rewritten = append(rewritten, r.Rewrite.Args...)
+	return append(rewritten, argv[at:]...)
+}
+
+// Match is a rule that matched a command, with how many words it covered
+type Match struct {
+	Rule  *Rule
+	Words int
+}
+
+// Registry holds the detection rules
+type Registry struct {
+	rules []*Rule
+}
+
+// NewRegistry merges user rules with the built-in rules
+func NewRegistry(user []Rule) (*Registry, error) {
+	r := &Registry{}
+	for _, rule := range user {
+		rule := rule
+		if err := rule.compile(); err != nil {
+			return nil, err
+		}
+		rule.order = len(r.rules)
+		r.rules = append(r.rules, &rule)
+	}
+
+	for _, rule := range builtinRules {
+		rule := rule
+		rule.Source = "built-in"
+		if err := rule.compile(); err != nil {
+			return nil, err
+		}
+		rule.order = len(r.rules)
+		r.rules = append(r.rules, &rule)
+	}
+	return r, nil
+}
+
+func mustRegistry(r *Registry, err error) *Registry {
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+// Matches returns every rule matching argv, most specific first: regex rules
+// come after the others whatever they cover, as ".*" covers everything; then
+// rules covering more words win, then exact over prefix over glob, then
+// longer patterns, then user rules over built-ins.
+func (r *Registry) Matches(argv []string) []Match {
+	var matches []Match
+	for _, rule := range r.rules {
+		if n := rule.match(argv); n > 0 {
+			matches = append(matches, Match{Rule: rule, Words: n})
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		a, b := matches[i], matches[j]
+		if ra, rb := a.Rule.Match == MatchRegex, b.Rule.Match == MatchRegex; ra != rb {
+			return rb
+		}
+		if a.Words != b.Words {
+			return a.Words > b.Words
+		}
+		if ra, rb := matchKindRank[a.Rule.Match], matchKindRank[b.Rule.Match]; ra != rb {
+			return ra > rb
+		}
+		if len(a.Rule.Pattern) != len(b.Rule.Pattern) {
+			return len(a.Rule.Pattern) > len(b.Rule.Pattern)
+		}
+		return a.Rule.order < b.Rule.order
+	})
+	return matches
+}
+
+// Detect strips wrappers such as sudo from argv and classifies the effective
+// command using the most specific matching rule
+func (r *Registry) Detect(argv []string) Detection {
+	cl := ParseCommandLine(argv)
+	matches := r.Matches(cl.Effective)
+	if len(matches) == 0 {
+		return Detection{Type: Generic, Description: "Running command", Command: cl}
+	}
+
+	rule := matches[0].Rule
+	return Detection{
+		Type:        rule.Type,
+		Description: rule.Description,
+		Interactive: rule.interactive(cl.Effective),
+		Suggestion:  rule.Suggestion,
+		Rule:        rule,
+		Command:     cl,
+	}
+}
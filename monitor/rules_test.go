@@ -0,0 +1,174 @@
+package monitor
+
+import "testing"
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		cmd         string
+		typ         CommandType
+		interactive bool
+		pattern     string // Pattern of the matching rule, "" for none
+	}{
+		{"echo npm init", Generic, false, ""},
+		{"npm init", NPM, true, "npm init"},
+		{"npm init -y", NPM, false, "npm init"},
+		{"npm init --yes", NPM, false, "npm init"},
+		{"npx create-next-app@latest my-app", NPM, true, "create-next-app"},
+		{"create-next-app@14 my-app --ts", NPM, false, "create-next-app"},
+		{"docker build -t app .", Docker, false, "docker build"},
+		{"docker buildx build -t app .", Generic, false, ""},
+		{"/usr/bin/docker pull nginx", Docker, false, "docker pull"},
+		{"sudo -E apt-get update", SystemPackages, false, "apt-get update"},
+		{"pip uninstall requests", Python, true, "pip uninstall"},
+		{"pip uninstall -y requests", Python, false, "pip uninstall"},
+		{"docker compose -f dev.yml up -d", Docker, false, "docker compose up"},
+		{"docker --context prod compose --profile web pull", Docker, false, "docker compose pull"},
+		{"terraform -chdir=infra init", Terraform, true, "terraform init"},
+		{"terraform plan -input=false -out plan", Terraform, false, "terraform plan"},
+		{"apt purge nginx", SystemPackages, true, "apt purge"},
+		{"apt-get -y upgrade", SystemPackages, false, "apt-get upgrade"},
+		{"dnf remove httpd", SystemPackages, true, "dnf remove"},
+		{"mvn -B archetype:generate", Java, false, "mvn archetype:generate"},
+	}
+	for _, tt := range tests {
+		argv, err := SplitCommandLine(tt.cmd)
+		if err != nil {
+			t.Fatalf("SplitCommandLine(%q): %v", tt.cmd, err)
+		}
+		d := DefaultRegistry.Detect(argv)
+		if d.Type != tt.typ || d.Interactive != tt.interactive {
+			t.Errorf("Detect(%q) = %s interactive=%v, want %s interactive=%v",
+				tt.cmd, d.Type, d.Interactive, tt.typ, tt.interactive)
+		}
+		pattern := ""
+		if d.Rule != nil {
+			pattern = d.Rule.Pattern
+		}
+		if pattern != tt.pattern {
+			t.Errorf("Detect(%q) matched rule %q, want %q", tt.cmd, pattern, tt.pattern)
+		}
+	}
+}
+
+func TestMatchesSpecificity(t *testing.T) {
+	r, err := NewRegistry([]Rule{
+		{Pattern: `^tool run`, Match: MatchRegex, Description: "regex"},
+		{Pattern: "tool r*", Match: MatchGlob, Description: "glob"},
+		{Pattern: "tool run", Match: MatchPrefix, Description: "prefix"},
+		{Pattern: "tool run", Match: MatchExact, Description: "exact"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		argv []string
+		want []string // Descriptions, most specific first
+	}{
+		{[]string{"tool", "run"}, []string{"exact", "prefix", "glob", "regex"}},
+		{[]string{"tool", "run", "--fast"}, []string{"prefix", "glob", "regex"}},
+		{[]string{"tool", "rebuild"}, []string{"glob"}},
+		{[]string{"other", "tool", "run"}, nil},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, m := range r.Matches(tt.argv) {
+			got = append(got, m.Rule.Description)
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("Matches(%q) = %q, want %q", tt.argv, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("Matches(%q) = %q, want %q", tt.argv, got, tt.want)
+				break
+			}
+		}
+	}
+}
+
+func TestApply(t *testing.T) {
+	tests := []struct {
+		cmd, want string
+	}{
+		{"npm init", "npm init -y"},
+		{"terraform -chdir=infra plan -out plan", "terraform -chdir=infra plan -input=false -out plan"},
+		{"mvn archetype:generate -DgroupId=com.example", "mvn archetype:generate -B -DgroupId=com.example"},
+		{"pnpm create vite my-app", "pnpm create --yes vite my-app"},
+	}
+	for _, tt := range tests {
+		argv, err := SplitCommandLine(tt.cmd)
+		if err != nil {
+			t.Fatal(err)
+		}
+		d := DefaultRegistry.Detect(argv)
+		if d.Rule == nil || d.Rule.Rewrite == nil {
+			t.Errorf("Detect(%q) has no rewrite", tt.cmd)
+			continue
+		}
+		if got := QuoteArgs(d.Rule.Apply(d.Command.Effective)); got != tt.want {
+			t.Errorf("Apply(%q) = %q, want %q", tt.cmd, got, tt.want)
+		}
+	}
+}
+
+func TestRegexLosesToLiteral(t *testing.T) {
+	r, err := NewRegistry([]Rule{
+		{Pattern: `.*`, Match: MatchRegex, Description: "anything"},
+		{Pattern: `^docker .*`, Match: MatchRegex, Description: "any docker"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		cmd, want string
+	}{
+		{"docker build -t app .", "Building Docker image"},
+		{"docker buildx build -t app .", "any docker"},
+		{"ls -la", "anything"},
+	}
+	for _, tt := range tests {
+		argv, err := SplitCommandLine(tt.cmd)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if d := r.Detect(argv); d.Description != tt.want {
+			t.Errorf("Detect(%q) = %q, want %q", tt.cmd, d.Description, tt.want)
+		}
+	}
+}
+
+func TestUserRuleWinsTie(t *testing.T) {
+	r, err := NewRegistry([]Rule{
+		{Pattern: "npm init", Type: Generic, Description: "Starting my package", Source: "config.json"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := r.Detect([]string{"npm", "init"})
+	if d.Rule == nil || d.Rule.Source != "config.json" {
+		t.Fatalf("Detect(npm init) used %+v, want the user rule", d.Rule)
+	}
+	if d.Description != "Starting my package" || d.Interactive {
+		t.Errorf("Detect(npm init) = %q interactive=%v, want the user rule's", d.Description, d.Interactive)
+	}
+}
+
+func TestWordEqual(t *testing.T) {
+	tests := []struct {
+		p, w string
+		want bool
+	}{
+		{"create-next-app", "create-next-app", true},
+		{"create-next-app", "create-next-app@latest", true},
+		{"create-next-app", "create-next-apps", false},
+		{"pkg@1", "pkg@1.2", false},
+		{"build", "buildx", false},
+	}
+	for _, tt := range tests {
+		if got := wordEqual(tt.p, tt.w); got != tt.want {
+			t.Errorf("wordEqual(%q, %q) = %v, want %v", tt.p, tt.w, got, tt.want)
+		}
+	}
+}
@@ -2,8 +2,10 @@ package monitor
 
 import (
 	"bytes"
+	"errors"
 	"os"
 	"os/exec"
+	"regexp"
 	"strings"
 	"sync"
 	"syscall"
@@ -18,11 +20,15 @@ const (
 	TaskRunning TaskState = iota
 	TaskCompleted
 	TaskFailed
+	TaskWaitingInput // Running but blocked on a prompt
 )
 
+var warningPattern = regexp.MustCompile(`(?i)\bwarn(ing)?\b`)
+
 type Task struct {
 	Cmd          *exec.Cmd
 	StartTime    time.Time
+	EndTime      time.Time
 	State        TaskState
 	Done         chan bool
 	Output       bytes.Buffer
@@ -37,6 +43,15 @@ type Task struct {
 	outputBuffer []string     // Buffer of recent output lines
 	bufferMu     sync.Mutex   // Mutex for the buffer
 	bufferSize   int          // Number of lines to keep in buffer
+	logFile      *os.File     // Optional copy of all output
+	partialLine  string       // Output after the last newline
+	recentLines  []string     // Last complete lines, for LinesSince
+	lastOutput   time.Time    // When the task last wrote anything
+	lineCount    int
+	warnCount    int
+	onComplete   []func(*Task)
+	handlers     sync.WaitGroup // OnComplete handlers still running
+	redactor     *Redactor
 }
 
 func NewTask(command string, args ...string) *Task {
@@ -64,81 +79,192 @@ func (t *Task) GetRecentOutput(maxLines int) []string {
 	return append([]string{}, t.outputBuffer[len(t.outputBuffer)-maxLines:]...)
 }
 
+// SetEnv adds NAME=value pairs to the command's environment
+func (t *Task) SetEnv(env []string) {
+	if t.Cmd.Env == nil {
+		t.Cmd.Env = os.Environ()
+	}
+	t.Cmd.Env = append(t.Cmd.Env, env...)
+}
+
+// SetLogFile copies all task output to the file at path
+func (t *Task) SetLogFile(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	t.logFile = f
+	return nil
+}
+
+// LogPath returns the path of the output log, or "" when not logging
+func (t *Task) LogPath() string {
+	if t.logFile == nil {
+		return ""
+	}
+	return t.logFile.Name()
+}
+
+// SetRedactor masks secrets in all output before it is stored or shown
+func (t *Task) SetRedactor(r *Redactor) {
+	t.redactor = r
+}
+
+// OnComplete registers fn to run after the command exits. The handlers run
+// in the background, so a slow one does not hold up Done.
+func (t *Task) OnComplete(fn func(*Task)) {
+	t.onComplete = append(t.onComplete, fn)
+}
+
+// WaitHandlers waits up to timeout for the OnComplete handlers to finish once
+// Done has fired, and reports whether they did
+func (t *Task) WaitHandlers(timeout time.Duration) bool {
+	finished := make(chan struct{})
+	go func() {
+		t.handlers.Wait()
+		close(finished)
+	}()
+	select {
+	case <-finished:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
 func (t *Task) Start() error {
 	var err error
+	t.StartTime = time.Now()
+	t.lastOutput = t.StartTime
 	t.pty, err = pty.Start(t.Cmd)
 	if err != nil {
 		return err
 	}
 
+	exited := make(chan struct{})
+	go t.watchInput(exited)
+
 	// Handle output in background with better buffer management
+	readDone := make(chan struct{})
 	go func() {
+		defer close(readDone)
+		defer close(t.output)
 		buf := make([]byte, 1024) // Smaller buffer for more frequent updates
+		var held string
 		for {
 			n, err := t.pty.Read(buf)
 			if err != nil {
-				if !t.isComplete {
+				if held != "" {
+					t.handleOutput(t.redactor.Redact(held))
+				}
+				// EIO just means every process closed the terminal
+				if !t.IsComplete() && !errors.Is(err, syscall.EIO) {
 					t.setError(err)
 				}
 				break
 			}
 
-			output := string(buf[:n])
-			lines := strings.Split(output, "\n")
-
-			t.outputMu.Lock()
-			// Limit buffer size to prevent memory issues
-			if t.Output.Len() > 100*1024 {
-				// Clear half the buffer if it gets too large
-				oldContent := t.Output.String()
-				t.Output.Reset()
-				t.Output.WriteString(oldContent[len(oldContent)/2:])
-			}
-			t.Output.WriteString(output)
-			t.outputMu.Unlock()
-
-			 // Add to buffer
-			t.bufferMu.Lock()
-			for _, line := range lines {
-				if line != "" {
-					t.outputBuffer = append(t.outputBuffer, line)
-					// Trim buffer if too large
-					if len(t.outputBuffer) > t.bufferSize {
-						t.outputBuffer = t.outputBuffer[1:]
-					}
+			output := held + string(buf[:n])
+			held = ""
+			// A full read may have cut a secret in two, so keep the last
+			// word back until the next read
+			if n == len(buf) {
+				if i := strings.LastIndexAny(output, " \t\r\n"); i >= 0 && len(output)-i <= 512 {
+					held = output[i+1:]
+					output = output[:i+1]
 				}
 			}
-			t.bufferMu.Unlock()
-
-			// Send to output channel with non-blocking write
-			select {
-			case t.output <- output:
-			default:
-				// Skip if channel is full
-			}
+			t.handleOutput(t.redactor.Redact(output))
 		}
 	}()
 
 	// Wait for completion
 	go func() {
 		err := t.Cmd.Wait()
+		close(exited)
 		t.statusMu.Lock()
 		t.isComplete = true
-		t.statusMu.Unlock()
-
+		t.EndTime = time.Now()
 		if err != nil {
-			t.setError(err)
 			t.State = TaskFailed
 		} else {
 			t.State = TaskCompleted
 		}
-		close(t.output)
+		t.statusMu.Unlock()
+
+		if err != nil {
+			t.setError(err)
+		}
+		// Let the reader drain what the command wrote before exiting
+		select {
+		case <-readDone:
+		case <-time.After(time.Second):
+		}
+
+		if t.logFile != nil {
+			t.outputMu.Lock()
+			t.logFile.Close()
+			t.outputMu.Unlock()
+		}
+		t.handlers.Add(1)
+		go func() {
+			defer t.handlers.Done()
+			for _, fn := range t.onComplete {
+				fn(t)
+			}
+		}()
 		t.Done <- true
 	}()
 
 	return nil
 }
 
+// Store output in the buffers and log, and forward it to listeners
+func (t *Task) handleOutput(output string) {
+	lines := strings.Split(output, "\n")
+
+	t.outputMu.Lock()
+	// Limit buffer size to prevent memory issues
+	if t.Output.Len() > 100*1024 {
+		// Clear half the buffer if it gets too large
+		oldContent := t.Output.String()
+		t.Output.Reset()
+		t.Output.WriteString(oldContent[len(oldContent)/2:])
+	}
+	t.Output.WriteString(output)
+	if t.logFile != nil {
+		t.logFile.WriteString(output)
+	}
+	t.countLines(output)
+	t.lastOutput = time.Now()
+	t.outputMu.Unlock()
+
+	// Add to buffer
+	t.bufferMu.Lock()
+	for _, line := range lines {
+		if line != "" {
+			t.outputBuffer = append(t.outputBuffer, line)
+			// Trim buffer if too large
+			if len(t.outputBuffer) > t.bufferSize {
+				t.outputBuffer = t.outputBuffer[1:]
+			}
+		}
+	}
+	t.bufferMu.Unlock()
+
+	// Send to output channel with non-blocking write
+	select {
+	case t.output <- output:
+	default:
+		// Skip if channel is full
+	}
+}
+
+// Write sends input to the task's terminal, e.g. the answer to a prompt
+func (t *Task) Write(p []byte) (int, error) {
+	return t.pty.Write(p)
+}
+
 func (t *Task) Stop() {
 	if t.Cmd != nil && t.Cmd.Process != nil {
 		t.Cmd.Process.Signal(syscall.SIGTERM)
@@ -186,3 +312,92 @@ func (t *Task) IsComplete() bool {
 func (t *Task) GetOutputChannel() <-chan string {
 	return t.output
 }
+
+// Count complete lines and warnings, carrying partial lines across reads.
+// Called with outputMu held.
+func (t *Task) countLines(output string) {
+	text := t.partialLine + output
+	lines := strings.Split(text, "\n")
+	t.partialLine = lines[len(lines)-1]
+	for _, line := range lines[:len(lines)-1] {
+		t.lineCount++
+		t.recentLines = append(t.recentLines, line)
+		if len(t.recentLines) > t.bufferSize {
+			t.recentLines = t.recentLines[1:]
+		}
+		if warningPattern.MatchString(line) {
+			t.warnCount++
+		}
+	}
+}
+
+// LineCount returns the number of output lines seen so far
+func (t *Task) LineCount() int {
+	t.outputMu.Lock()
+	defer t.outputMu.Unlock()
+	if t.partialLine != "" {
+		return t.lineCount + 1
+	}
+	return t.lineCount
+}
+
+// LinesSince returns the complete output lines after the first n that are
+// still kept, and the number of complete lines so far
+func (t *Task) LinesSince(n int) ([]string, int) {
+	t.outputMu.Lock()
+	defer t.outputMu.Unlock()
+	first := t.lineCount - len(t.recentLines)
+	if n < first {
+		n = first
+	}
+	if n > t.lineCount {
+		n = t.lineCount
+	}
+	return append([]string{}, t.recentLines[n-first:]...), t.lineCount
+}
+
+// WarningCount returns the number of output lines mentioning a warning
+func (t *Task) WarningCount() int {
+	t.outputMu.Lock()
+	defer t.outputMu.Unlock()
+	if warningPattern.MatchString(t.partialLine) {
+		return t.warnCount + 1
+	}
+	return t.warnCount
+}
+
+// DisplayCommand returns the command line with secrets masked
+func (t *Task) DisplayCommand() string {
+	return t.redactor.Redact(t.CommandLine())
+}
+
+// ExitCode returns the command's exit status, or -1 while it is still running
+func (t *Task) ExitCode() int {
+	if !t.IsComplete() || t.Cmd.ProcessState == nil {
+		return -1
+	}
+	return t.Cmd.ProcessState.ExitCode()
+}
+
+// Duration returns how long the task ran, or has been running so far
+func (t *Task) Duration() time.Duration {
+	t.statusMu.Lock()
+	defer t.statusMu.Unlock()
+	if t.isComplete {
+		return t.EndTime.Sub(t.StartTime)
+	}
+	return time.Since(t.StartTime)
+}
+
+// Status returns the task's state. The input watcher changes it while the
+// task runs, so read it through here rather than the State field.
+func (t *Task) Status() TaskState {
+	t.statusMu.Lock()
+	defer t.statusMu.Unlock()
+	return t.State
+}
+
+// CommandLine returns the command and its arguments joined by spaces
+func (t *Task) CommandLine() string {
+	return strings.Join(t.Cmd.Args, " ")
+}
@@ -0,0 +1,124 @@
+package monitor
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// How long a task must be silent before we consider it to be waiting
+const inputIdleThreshold = 2 * time.Second
+
+// Confirmation questions and password prompts
+var promptPattern = regexp.MustCompile(`(?i)(\[y/n\]|\(y/n\)|\[yes/no\]|\(yes/no\)|password|passphrase|continue\?)`)
+
+// looksLikePrompt reports whether line reads like a question to the user
+func looksLikePrompt(line string) bool {
+	line = strings.TrimRight(line, " \t")
+	if line == "" {
+		return false
+	}
+	if promptPattern.MatchString(line) {
+		return true
+	}
+	last, _ := utf8.DecodeLastRuneInString(line)
+	return strings.ContainsRune("?:>›»", last)
+}
+
+// watchInput polls the task until it exits, switching between TaskRunning
+// and TaskWaitingInput
+func (t *Task) watchInput(done <-chan struct{}) {
+	ticker := time.NewTicker(500 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+		}
+
+		t.outputMu.Lock()
+		idle := time.Since(t.lastOutput)
+		prompt := t.promptLine()
+		t.outputMu.Unlock()
+
+		waiting := false
+		if idle >= inputIdleThreshold {
+			// A process blocked reading the terminal is waiting for us
+			// whatever its last line says
+			waiting = looksLikePrompt(prompt) || readingTerminal(t.Cmd.Process.Pid)
+		}
+
+		t.statusMu.Lock()
+		if !t.isComplete {
+			if waiting {
+				t.State = TaskWaitingInput
+			} else {
+				t.State = TaskRunning
+			}
+		}
+		t.statusMu.Unlock()
+	}
+}
+
+// promptLine returns the text after the last line break, which is where a
+// prompt sits. Called with outputMu held.
+func (t *Task) promptLine() string {
+	line := t.partialLine
+	if i := strings.LastIndex(line, "\r"); i >= 0 {
+		line = line[i+1:]
+	}
+	return strings.TrimSpace(StripANSI(line))
+}
+
+// WaitingForInput reports whether the task seems to be blocked on a prompt
+func (t *Task) WaitingForInput() bool {
+	return t.Status() == TaskWaitingInput
+}
+
+// Prompt returns the last incomplete output line, usually the question the
+// task is waiting on
+func (t *Task) Prompt() string {
+	t.outputMu.Lock()
+	defer t.outputMu.Unlock()
+	return t.promptLine()
+}
+
+// readingTerminal reports whether pid or one of its descendants is blocked
+// reading its input. It returns false where /proc is unavailable.
+func readingTerminal(pid int) bool {
+	dir := filepath.Join("/proc", strconv.Itoa(pid))
+	if readingStdin(dir) {
+		return true
+	}
+
+	children, err := os.ReadFile(filepath.Join(dir, "task", strconv.Itoa(pid), "children"))
+	if err != nil {
+		return false
+	}
+	for _, field := range strings.Fields(string(children)) {
+		if child, err := strconv.Atoi(field); err == nil && readingTerminal(child) {
+			return true
+		}
+	}
+	return false
+}
+
+// readingStdin reports whether the process whose /proc directory is dir is
+// blocked in a read of fd 0. /proc/<pid>/syscall says so directly. Where it
+// can't be read, e.g. behind sudo, older kernels still name n_tty_read as the
+// wait channel; the wait_woken of newer ones is shared with socket waits, so
+// a quiet download would look like a prompt.
+func readingStdin(dir string) bool {
+	if data, err := os.ReadFile(filepath.Join(dir, "syscall")); err == nil {
+		fields := strings.Fields(string(data))
+		return sysRead >= 0 && len(fields) >= 2 && fields[0] == strconv.Itoa(sysRead) && fields[1] == "0x0"
+	}
+	wchan, err := os.ReadFile(filepath.Join(dir, "wchan"))
+	return err == nil && string(wchan) == "n_tty_read"
+}
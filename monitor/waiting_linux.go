@@ -0,0 +1,6 @@
+package monitor
+
+import "syscall"
+
+// Number of the read system call in /proc/<pid>/syscall
+const sysRead = syscall.SYS_READ
@@ -0,0 +1,6 @@
+//go:build !linux
+
+package monitor
+
+// There is no /proc/<pid>/syscall to compare with
+const sysRead = -1
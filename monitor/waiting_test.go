@@ -0,0 +1,57 @@
+package monitor
+
+import (
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestLooksLikePrompt(t *testing.T) {
+	tests := []struct {
+		line string
+		want bool
+	}{
+		{"Do you want to continue? [Y/n] ", true},
+		{"Password:", true},
+		{"package name: ", true},
+		{"Enter passphrase for key '/root/.ssh/id_ed25519'", true},
+		{"? Project name ›", true},
+		{"Downloading 3 packages", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := looksLikePrompt(tt.line); got != tt.want {
+			t.Errorf("looksLikePrompt(%q) = %v, want %v", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestReadingTerminal(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("needs /proc")
+	}
+	tests := []struct {
+		args []string
+		want bool
+	}{
+		{[]string{"cat"}, true},
+		{[]string{"sh", "-c", "cat"}, true},
+		{[]string{"sleep", "5"}, false},
+	}
+	for _, tt := range tests {
+		task := NewTask(tt.args[0], tt.args[1:]...)
+		if err := task.Start(); err != nil {
+			t.Fatal(err)
+		}
+		got := false
+		for deadline := time.Now().Add(time.Second); !got && time.Now().Before(deadline); {
+			time.Sleep(50 * time.Millisecond)
+			got = readingTerminal(task.Cmd.Process.Pid)
+		}
+		task.Cmd.Process.Kill()
+		<-task.Done
+		if got != tt.want {
+			t.Errorf("readingTerminal(%q) = %v, want %v", tt.args, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+// Package store keeps DevTyper's state, such as timings and best scores, in
+// JSON files
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// Load reads the JSON file at path into v. A missing file leaves v as it is,
+// so callers start from their empty value; a file that can't be read or
+// parsed is an error.
+func Load(path string, v interface{}) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("%s is corrupt: %w", path, err)
+	}
+	return nil
+}
+
+// Save writes v to the file at path as indented JSON
+func Save(path string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
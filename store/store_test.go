@@ -0,0 +1,33 @@
+package store
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "state.json")
+
+	state := map[string]int{"kept": 1}
+	if err := Load(path, &state); err != nil || state["kept"] != 1 {
+		t.Fatalf("Load of a missing file = %v, %v, want no error and the value untouched", err, state)
+	}
+
+	if err := Save(path, map[string]int{"a": 2}); err != nil {
+		t.Fatal(err)
+	}
+	state = map[string]int{}
+	if err := Load(path, &state); err != nil || state["a"] != 2 {
+		t.Fatalf("Load after Save = %v, %v, want a=2", err, state)
+	}
+
+	if err := os.WriteFile(path, []byte("{not json"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := Load(path, &state); err == nil || !strings.Contains(err.Error(), "corrupt") {
+		t.Errorf("Load of a corrupt file = %v, want it reported", err)
+	}
+}